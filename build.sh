@@ -4,13 +4,18 @@
 # https://github.com/golang/go/blob/master/src/go/build/syslist.go
 os_archs=(
     darwin/amd64
+    darwin/arm64
     linux/amd64
+    linux/arm64
     windows/amd64
 )
 
 releases=()
 fails=()
 
+# the version of denox, eg v0.1.0
+version=$(git describe --tags --always 2>/dev/null || echo dev)
+
 for os_arch in "${os_archs[@]}"
 do
     goos=${os_arch%/*}
@@ -25,7 +30,7 @@ do
 
     echo building ${os_arch}
 
-    CGO_ENABLED=0 GOOS=${goos} GOARCH=${goarch} go build -gcflags=-trimpath=$GOPATH -asmflags=-trimpath=$GOPATH -ldflags "-s -w" -o ./bin/${filename} main.go
+    CGO_ENABLED=0 GOOS=${goos} GOARCH=${goarch} go build -gcflags=-trimpath=$GOPATH -asmflags=-trimpath=$GOPATH -ldflags "-s -w -X main.version=${version}" -o ./bin/${filename} .
 
     # if build success
     if [[ $? == 0 ]];then
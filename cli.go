@@ -0,0 +1,291 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"runtime"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/axetroy/denox/internal/deno"
+	"github.com/axetroy/denox/internal/logger"
+	"github.com/pkg/errors"
+)
+
+// the version of denox, it is set with `-ldflags "-X main.version=v0.1.0"` when building
+var version = "dev"
+
+// the flag of denox before the args of Deno, eg `denox --deno-version ^1.4 run main.ts`
+const denoVersionFlag = "--deno-version"
+
+// a command of denox, it runs with `denox x <name>`.
+// the subcommand `x` is reserved, so that any other args are passed to Deno as it is
+type command interface {
+	name() string
+	// the args after the name, eg `[flags] <range...>`
+	usage() string
+	description() string
+	setFlags(flags *flag.FlagSet)
+	// run with the args which are not flags
+	run(args []string) error
+}
+
+// the error of the usage, the usage of the command is printed with it
+type usageError struct {
+	message string
+}
+
+func (e *usageError) Error() string {
+	return e.message
+}
+
+func newUsageError(format string, args ...interface{}) error {
+	return &usageError{message: fmt.Sprintf(format, args...)}
+}
+
+var commands []command
+
+func init() {
+	commands = []command{
+		&installCommand{},
+		&uninstallCommand{},
+		&listCommand{},
+		&listRemoteCommand{},
+		&configCommand{},
+		&helpCommand{},
+		&versionCommand{},
+	}
+}
+
+func findCommand(name string) command {
+	for _, c := range commands {
+		if c.name() == name {
+			return c
+		}
+	}
+
+	return nil
+}
+
+// create the flag set of the command, it prints nothing, the errors are returned by Parse
+func newFlagSet(c command) *flag.FlagSet {
+	flags := flag.NewFlagSet(c.name(), flag.ContinueOnError)
+	flags.SetOutput(ioutil.Discard)
+	flags.Usage = func() {}
+
+	c.setFlags(flags)
+
+	return flags
+}
+
+// parse the flags which may be after the args, eg `config get --show-origin version`.
+// the args after `--` are never parsed as flags
+func parseFlags(flags *flag.FlagSet, args []string) ([]string, error) {
+	rest := make([]string, 0, len(args))
+
+	for len(args) > 0 {
+		if err := flags.Parse(args); err != nil {
+			return nil, err
+		}
+
+		remaining := flags.Args()
+
+		if parsed := args[:len(args)-len(remaining)]; len(parsed) > 0 && parsed[len(parsed)-1] == "--" {
+			return append(rest, remaining...), nil
+		}
+
+		if len(remaining) == 0 {
+			break
+		}
+
+		rest = append(rest, remaining[0])
+		args = remaining[1:]
+	}
+
+	return rest, nil
+}
+
+func printUsage(c command, w io.Writer) {
+	usage := strings.Replace(c.usage(), "\n", "\n       denox x "+c.name()+" ", -1)
+
+	_, _ = fmt.Fprintf(w, "usage: denox x %s %s\n\n%s\n", c.name(), usage, c.description())
+
+	flags := newFlagSet(c)
+	hasFlags := false
+
+	flags.VisitAll(func(*flag.Flag) { hasFlags = true })
+
+	if hasFlags {
+		_, _ = fmt.Fprintf(w, "\nflags:\n")
+		flags.SetOutput(w)
+		flags.PrintDefaults()
+	}
+}
+
+// run the command of denox and returns the exit code
+func runCommand(args []string) int {
+	name := "help"
+
+	if len(args) > 0 {
+		name, args = args[0], args[1:]
+	}
+
+	switch name {
+	case "-h", "--help":
+		name = "help"
+	case "-v", "--version":
+		name = "version"
+	}
+
+	c := findCommand(name)
+
+	if c == nil {
+		_, _ = fmt.Fprintf(os.Stderr, "denox: unknown command `%s`, see `denox x help`\n", name)
+		return 2
+	}
+
+	args, err := parseFlags(newFlagSet(c), args)
+
+	if err == flag.ErrHelp {
+		printUsage(c, os.Stdout)
+		return 0
+	} else if err != nil {
+		err = newUsageError("%s", err)
+	} else {
+		err = c.run(args)
+	}
+
+	if e, ok := err.(*usageError); ok {
+		_, _ = fmt.Fprintf(os.Stderr, "denox: %s\n\n", e.message)
+		printUsage(c, os.Stderr)
+		return 2
+	} else if err != nil {
+		if logger.Verbose {
+			_, _ = fmt.Fprintf(os.Stderr, "denox: %+v\n", err)
+		} else {
+			_, _ = fmt.Fprintf(os.Stderr, "denox: %s\n", err)
+		}
+
+		return 1
+	}
+
+	return 0
+}
+
+// parse the leading flags of denox, returns the rest args for Deno
+func parseDenoxFlags(args []string) (denoVersion string, denoArgs []string, err error) {
+	for len(args) > 0 {
+		arg := args[0]
+
+		switch {
+		case arg == denoVersionFlag:
+			if len(args) < 2 || args[1] == "" {
+				return "", nil, newUsageError("flag %s requires a version range", denoVersionFlag)
+			}
+
+			denoVersion, args = args[1], args[2:]
+		case strings.HasPrefix(arg, denoVersionFlag+"="):
+			if denoVersion = strings.TrimPrefix(arg, denoVersionFlag+"="); denoVersion == "" {
+				return "", nil, newUsageError("flag %s requires a version range", denoVersionFlag)
+			}
+
+			args = args[1:]
+		default:
+			return denoVersion, args, nil
+		}
+	}
+
+	return denoVersion, args, nil
+}
+
+type helpCommand struct{}
+
+func (*helpCommand) name() string { return "help" }
+
+func (*helpCommand) usage() string { return "[command]" }
+
+func (*helpCommand) description() string { return "print the help of denox or the command" }
+
+func (*helpCommand) setFlags(*flag.FlagSet) {}
+
+func (*helpCommand) run(args []string) error {
+	if len(args) > 1 {
+		return newUsageError("too many arguments")
+	}
+
+	if len(args) == 1 {
+		c := findCommand(args[0])
+
+		if c == nil {
+			return newUsageError("unknown command `%s`", args[0])
+		}
+
+		printUsage(c, os.Stdout)
+
+		return nil
+	}
+
+	fmt.Printf("denox %s, run any version of Deno without installing it\n\n", version)
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+
+	_, _ = fmt.Fprintf(w, "usage:\n")
+	_, _ = fmt.Fprintf(w, "  denox [%s <range>] [args...]\trun Deno with the args, eg `denox run main.ts`\n", denoVersionFlag)
+	_, _ = fmt.Fprintf(w, "  denox x <command> [args...]\trun the command of denox\n")
+	_, _ = fmt.Fprintf(w, "\ncommands:\n")
+
+	for _, c := range commands {
+		// the first line only
+		_, _ = fmt.Fprintf(w, "  %s\t%s\n", c.name(), strings.SplitN(c.description(), "\n", 2)[0])
+	}
+
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	fmt.Printf("\nrun `denox x help <command>` for the usage of the command\n")
+
+	return nil
+}
+
+type versionCommand struct{}
+
+func (*versionCommand) name() string { return "version" }
+
+func (*versionCommand) usage() string { return "" }
+
+func (*versionCommand) description() string {
+	return "print the version of denox and the version range of Deno to use in the current working dir"
+}
+
+func (*versionCommand) setFlags(*flag.FlagSet) {}
+
+func (*versionCommand) run(args []string) error {
+	if len(args) > 0 {
+		return newUsageError("too many arguments")
+	}
+
+	fmt.Printf("denox %s (%s/%s, %s)\n", version, runtime.GOOS, runtime.GOARCH, runtime.Version())
+
+	cwd, err := os.Getwd()
+
+	if err != nil {
+		return errors.Wrap(err, "get current working dir fail")
+	}
+
+	spec, from, err := deno.LookupVersion(cwd)
+
+	if err != nil {
+		return err
+	}
+
+	if spec == nil {
+		fmt.Printf("deno latest\n")
+	} else {
+		fmt.Printf("deno %s (%s)\n", *spec, from)
+	}
+
+	return nil
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"flag"
+	"io/ioutil"
+	"reflect"
+	"testing"
+)
+
+func TestParseFlags(t *testing.T) {
+	tests := []struct {
+		args       []string
+		rest       []string
+		showOrigin bool
+		err        bool
+	}{
+		{args: []string{}, rest: []string{}},
+		{args: []string{"version"}, rest: []string{"version"}},
+		{args: []string{"--show-origin", "version"}, rest: []string{"version"}, showOrigin: true},
+		// the flags after the args
+		{args: []string{"version", "--show-origin"}, rest: []string{"version"}, showOrigin: true},
+		{args: []string{"a", "-show-origin", "b"}, rest: []string{"a", "b"}, showOrigin: true},
+		// the args after `--` are never parsed as flags
+		{args: []string{"--", "--show-origin"}, rest: []string{"--show-origin"}},
+		{args: []string{"a", "--", "b", "--show-origin"}, rest: []string{"a", "b", "--show-origin"}},
+		{args: []string{"version", "--unknown"}, err: true},
+	}
+
+	for _, test := range tests {
+		flags := flag.NewFlagSet("test", flag.ContinueOnError)
+		flags.SetOutput(ioutil.Discard)
+
+		showOrigin := flags.Bool("show-origin", false, "")
+
+		rest, err := parseFlags(flags, test.args)
+
+		if test.err {
+			if err == nil {
+				t.Errorf("expect error for %v, got nil", test.args)
+			}
+
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("parse %v fail: %v", test.args, err)
+			continue
+		}
+
+		if !reflect.DeepEqual(rest, test.rest) {
+			t.Errorf("expect args %v for %v, got %v", test.rest, test.args, rest)
+		}
+
+		if *showOrigin != test.showOrigin {
+			t.Errorf("expect flag to be %v for %v, got %v", test.showOrigin, test.args, *showOrigin)
+		}
+	}
+}
+
+func TestParseDenoxFlags(t *testing.T) {
+	tests := []struct {
+		args        []string
+		denoVersion string
+		denoArgs    []string
+		usage       bool
+	}{
+		{args: []string{}, denoArgs: []string{}},
+		{args: []string{"run", "main.ts"}, denoArgs: []string{"run", "main.ts"}},
+		{args: []string{"--deno-version", "^1.4", "run", "main.ts"}, denoVersion: "^1.4", denoArgs: []string{"run", "main.ts"}},
+		{args: []string{"--deno-version=1.x", "--version"}, denoVersion: "1.x", denoArgs: []string{"--version"}},
+		// the last one wins
+		{args: []string{"--deno-version", "1.x", "--deno-version=2.x"}, denoVersion: "2.x", denoArgs: []string{}},
+		// the flag after the args of Deno belongs to Deno
+		{args: []string{"run", "--deno-version", "1.x"}, denoArgs: []string{"run", "--deno-version", "1.x"}},
+		{args: []string{"--deno-version"}, usage: true},
+		{args: []string{"--deno-version", ""}, usage: true},
+		{args: []string{"--deno-version="}, usage: true},
+	}
+
+	for _, test := range tests {
+		denoVersion, denoArgs, err := parseDenoxFlags(test.args)
+
+		if test.usage {
+			if _, ok := err.(*usageError); !ok {
+				t.Errorf("expect usage error for %v, got %v", test.args, err)
+			}
+
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("parse %v fail: %v", test.args, err)
+			continue
+		}
+
+		if denoVersion != test.denoVersion {
+			t.Errorf("expect version `%s` for %v, got `%s`", test.denoVersion, test.args, denoVersion)
+		}
+
+		if len(denoArgs) != len(test.denoArgs) || (len(denoArgs) > 0 && !reflect.DeepEqual(denoArgs, test.denoArgs)) {
+			t.Errorf("expect args %v for %v, got %v", test.denoArgs, test.args, denoArgs)
+		}
+	}
+}
@@ -0,0 +1,172 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"text/tabwriter"
+
+	"github.com/axetroy/denox/internal/config"
+	"github.com/pkg/errors"
+)
+
+type configCommand struct {
+	showOrigin bool
+	system     bool
+	user       bool
+	project    bool
+}
+
+func (*configCommand) name() string { return "config" }
+
+func (*configCommand) usage() string {
+	return "list\nget [--show-origin] <key>\nset [--system|--user|--project] <key> <value>\nunset [--system|--user|--project] <key>"
+}
+
+func (*configCommand) description() string {
+	return "inspect and change the config\n" +
+		"the environment variables override the project config, which overrides the user config, which overrides the system config"
+}
+
+func (c *configCommand) setFlags(flags *flag.FlagSet) {
+	flags.BoolVar(&c.showOrigin, "show-origin", false, "print where the value comes from")
+	flags.BoolVar(&c.system, "system", false, "change the system config")
+	flags.BoolVar(&c.user, "user", false, "change the user config, the default")
+	flags.BoolVar(&c.project, "project", false, "change the project config, the nearest .denoxrc or the one in the current working dir")
+}
+
+func (c *configCommand) run(args []string) error {
+	if len(args) == 0 {
+		return newUsageError("config requires a command")
+	}
+
+	action, args := args[0], args[1:]
+
+	cwd, err := os.Getwd()
+
+	if err != nil {
+		return errors.Wrap(err, "get current working dir fail")
+	}
+
+	switch action {
+	case "list":
+		if len(args) != 0 {
+			return newUsageError("too many arguments")
+		}
+
+		return listConfig(config.Load(cwd))
+	case "get":
+		if len(args) != 1 {
+			return newUsageError("config get requires a key")
+		}
+
+		if _, err := config.GetKey(args[0]); err != nil {
+			return err
+		}
+
+		v, ok := config.Load(cwd).Lookup(args[0])
+
+		if !ok {
+			return errors.Errorf("config `%s` is not set", args[0])
+		}
+
+		if c.showOrigin {
+			fmt.Printf("%s\t%s\n", v.Origin(), v.Value)
+		} else {
+			fmt.Println(v.Value)
+		}
+
+		return nil
+	case "set", "unset":
+		if action == "set" && len(args) != 2 {
+			return newUsageError("config set requires a key and a value")
+		} else if action == "unset" && len(args) != 1 {
+			return newUsageError("config unset requires a key")
+		}
+
+		key, err := config.GetKey(args[0])
+
+		if err != nil {
+			return err
+		}
+
+		layer, err := c.layer()
+
+		if err != nil {
+			return err
+		}
+
+		file, err := config.GetFilePath(layer, cwd)
+
+		if err != nil {
+			return err
+		}
+
+		// create the project config in the current working dir
+		if file == "" {
+			file = filepath.Join(cwd, config.ProjectFilename)
+		}
+
+		value := ""
+
+		if action == "set" {
+			value = args[1]
+		}
+
+		if err := config.SetValue(file, key.Name, value); err != nil {
+			return errors.Wrapf(err, "%s config `%s` fail", action, key.Name)
+		}
+
+		if v := os.Getenv(key.Env); v != "" {
+			_, _ = fmt.Fprintf(os.Stderr, "config `%s` is overridden by environment variable %s=%s\n", key.Name, key.Env, v)
+		}
+
+		return nil
+	default:
+		return newUsageError("unknown config command `%s`", action)
+	}
+}
+
+// get the layer to change from the flags, the user config by default
+func (c *configCommand) layer() (config.Layer, error) {
+	layers := make([]config.Layer, 0, 1)
+
+	if c.system {
+		layers = append(layers, config.LayerSystem)
+	}
+
+	if c.user {
+		layers = append(layers, config.LayerUser)
+	}
+
+	if c.project {
+		layers = append(layers, config.LayerProject)
+	}
+
+	switch len(layers) {
+	case 0:
+		return config.LayerUser, nil
+	case 1:
+		return layers[0], nil
+	default:
+		return "", newUsageError("only one of --system, --user and --project can be used")
+	}
+}
+
+// print the effective values of all keys and where they come from
+func listConfig(c *config.Config) error {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintln(w, "KEY\tVALUE\tORIGIN")
+
+	for _, key := range config.Keys {
+		if v, ok := c.Lookup(key.Name); ok {
+			fmt.Fprintf(w, "%s\t%s\t%s\n", key.Name, v.Value, v.Origin())
+		} else {
+			fmt.Fprintf(w, "%s\t\t%s\n", key.Name, "not set, "+key.Description)
+		}
+	}
+
+	return w.Flush()
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/axetroy/denox/internal/config"
+)
+
+func TestConfigLayer(t *testing.T) {
+	tests := []struct {
+		command configCommand
+		layer   config.Layer
+		usage   bool
+	}{
+		{command: configCommand{}, layer: config.LayerUser},
+		{command: configCommand{system: true}, layer: config.LayerSystem},
+		{command: configCommand{user: true}, layer: config.LayerUser},
+		{command: configCommand{project: true}, layer: config.LayerProject},
+		{command: configCommand{system: true, project: true}, usage: true},
+		{command: configCommand{user: true, project: true}, usage: true},
+	}
+
+	for _, test := range tests {
+		layer, err := test.command.layer()
+
+		if test.usage {
+			if _, ok := err.(*usageError); !ok {
+				t.Errorf("expect usage error for %+v, got %v and %v", test.command, layer, err)
+			}
+
+			continue
+		}
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if layer != test.layer {
+			t.Errorf("expect layer `%s` for %+v, got `%s`", test.layer, test.command, layer)
+		}
+	}
+}
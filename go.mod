@@ -5,4 +5,5 @@ go 1.13
 require (
 	github.com/cheggaaa/pb/v3 v3.0.4
 	github.com/pkg/errors v0.9.1
+	github.com/ulikunitz/xz v0.5.15
 )
@@ -11,6 +11,9 @@ get_arch() {
     "x86_64" | "amd64" )
         echo "amd64"
         ;;
+    "aarch64" | "arm64" )
+        echo "arm64"
+        ;;
     "i386" | "i486" | "i586")
         echo "386"
         ;;
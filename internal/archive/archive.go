@@ -0,0 +1,363 @@
+package archive
+
+import (
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// the limits of the extracted data, the binary of Deno is about 100 MB
+	DefaultMaxFileSize  = 1 << 30
+	DefaultMaxTotalSize = 2 << 30
+)
+
+var (
+	ErrUnknownFormat      = errors.New("unknown archive format")
+	ErrUnsafePath         = errors.New("unsafe path in archive")
+	ErrTooLarge           = errors.New("archive is too large")
+	ErrExecutableNotFound = errors.New("executable not found in archive")
+)
+
+// Format of the archive
+type Format string
+
+const (
+	FormatZip   Format = "zip"
+	FormatTar   Format = "tar"
+	FormatTarGz Format = "tar.gz"
+	FormatTarXz Format = "tar.xz"
+	// a single file compressed with gzip, eg `deno_linux_x64.gz`
+	FormatGz Format = "gz"
+)
+
+// Options of the extraction
+type Options struct {
+	// max size of a single file
+	MaxFileSize int64
+	// max size of all files
+	MaxTotalSize int64
+}
+
+// DefaultOptions returns the options with default limits
+func DefaultOptions() Options {
+	return Options{
+		MaxFileSize:  DefaultMaxFileSize,
+		MaxTotalSize: DefaultMaxTotalSize,
+	}
+}
+
+// DetectFormat detect the format of archive by the file name
+func DetectFormat(name string) (Format, error) {
+	name = strings.ToLower(name)
+
+	switch {
+	case strings.HasSuffix(name, ".zip"):
+		return FormatZip, nil
+	case strings.HasSuffix(name, ".tar.gz"), strings.HasSuffix(name, ".tgz"):
+		return FormatTarGz, nil
+	case strings.HasSuffix(name, ".tar.xz"), strings.HasSuffix(name, ".txz"):
+		return FormatTarXz, nil
+	case strings.HasSuffix(name, ".tar"):
+		return FormatTar, nil
+	case strings.HasSuffix(name, ".gz"):
+		return FormatGz, nil
+	}
+
+	return "", errors.Wrapf(ErrUnknownFormat, "`%s`", name)
+}
+
+// Extract the archive file into dest, returns the regular files extracted, in slash separated path relative to dest.
+// the entries which escape from dest are rejected
+func Extract(file string, dest string, options Options) ([]string, error) {
+	format, err := DetectFormat(file)
+
+	if err != nil {
+		return nil, err
+	}
+
+	// zip is read with random access, so that the file modes in the central directory are kept
+	if format == FormatZip {
+		e := &extractor{dest: filepath.Clean(dest), options: options}
+
+		if err := e.extractZip(file); err != nil {
+			return nil, err
+		}
+
+		if err := e.checkSymlinks(); err != nil {
+			return nil, err
+		}
+
+		return e.files, nil
+	}
+
+	f, err := os.Open(file)
+
+	if err != nil {
+		return nil, errors.Wrapf(err, "open file `%s` fail", file)
+	}
+
+	defer f.Close()
+
+	return ExtractReader(f, filepath.Base(file), dest, options)
+}
+
+// ExtractReader extract the archive from the reader into dest, the format is detected by the name.
+// zip is read in streaming mode, which knows nothing about the file modes and symlinks.
+// the reader may not be read to the end
+func ExtractReader(reader io.Reader, name string, dest string, options Options) ([]string, error) {
+	format, err := DetectFormat(name)
+
+	if err != nil {
+		return nil, err
+	}
+
+	e := &extractor{dest: filepath.Clean(dest), options: options}
+
+	switch format {
+	case FormatZip:
+		err = e.extractZipStream(reader)
+	case FormatGz:
+		err = e.extractGz(reader, strings.TrimSuffix(path.Base(name), path.Ext(name)))
+	default:
+		err = e.extractTarStream(reader, format)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err := e.checkSymlinks(); err != nil {
+		return nil, err
+	}
+
+	return e.files, nil
+}
+
+// FindExecutable find the executable by name in the extracted files, returns the path relative to dest.
+// if there is only one file, it is the executable
+func FindExecutable(files []string, name string) (string, error) {
+	matches := make([]string, 0)
+
+	for _, file := range files {
+		base := path.Base(file)
+
+		if base == name || (runtime.GOOS == "windows" && strings.EqualFold(base, name)) {
+			matches = append(matches, file)
+		}
+	}
+
+	if len(matches) > 0 {
+		// prefer the one near the root, eg `deno` rather than `lib/deno`
+		sort.Slice(matches, func(i, j int) bool {
+			return strings.Count(matches[i], "/") < strings.Count(matches[j], "/")
+		})
+
+		return matches[0], nil
+	}
+
+	if len(files) == 1 {
+		return files[0], nil
+	}
+
+	return "", errors.Wrapf(ErrExecutableNotFound, "`%s` not in [%s]", name, strings.Join(files, ", "))
+}
+
+type extractor struct {
+	dest    string
+	options Options
+	// the size of the extracted files
+	total int64
+	files []string
+	// the symlinks extracted, in the pair of name and link name
+	links [][2]string
+}
+
+// get the path of the entry in dest, the entry must not escape from dest
+func (e *extractor) path(name string) (string, error) {
+	name = strings.ReplaceAll(name, "\\", "/")
+
+	if name == "" || strings.HasPrefix(name, "/") || filepath.VolumeName(name) != "" || (runtime.GOOS == "windows" && strings.Contains(name, ":")) {
+		return "", errors.Wrapf(ErrUnsafePath, "`%s`", name)
+	}
+
+	for _, part := range strings.Split(name, "/") {
+		if part == ".." {
+			return "", errors.Wrapf(ErrUnsafePath, "`%s`", name)
+		}
+	}
+
+	target := filepath.Join(e.dest, filepath.FromSlash(name))
+
+	// do not write through the symlinks in the archive, they may point to outside
+	for dir := filepath.Dir(target); dir != e.dest && len(dir) > len(e.dest); dir = filepath.Dir(dir) {
+		if stat, err := os.Lstat(dir); err == nil && stat.Mode()&os.ModeSymlink != 0 {
+			return "", errors.Wrapf(ErrUnsafePath, "`%s` is inside a symlink", name)
+		}
+	}
+
+	if stat, err := os.Lstat(target); err == nil && stat.Mode()&os.ModeSymlink != 0 {
+		return "", errors.Wrapf(ErrUnsafePath, "`%s` overwrites a symlink", name)
+	}
+
+	return target, nil
+}
+
+func (e *extractor) mkdir(name string) error {
+	target, err := e.path(name)
+
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(target, 0755); err != nil {
+		return errors.Wrapf(err, "create dir `%s` fail", target)
+	}
+
+	return nil
+}
+
+// write the regular file with the data of reader
+func (e *extractor) writeFile(name string, mode os.FileMode, reader io.Reader) error {
+	target, err := e.path(name)
+
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return errors.Wrapf(err, "create dir `%s` fail", filepath.Dir(target))
+	}
+
+	// keep the permission bits only, and the owner can always read and write it
+	mode = mode.Perm() | 0600
+
+	writer, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, mode)
+
+	if err != nil {
+		return errors.Wrapf(err, "create file `%s` fail", target)
+	}
+
+	// the max bytes can be written, -1 means no limit
+	limit := int64(-1)
+
+	if e.options.MaxFileSize > 0 {
+		limit = e.options.MaxFileSize
+	}
+
+	if rest := e.options.MaxTotalSize - e.total; e.options.MaxTotalSize > 0 && (limit < 0 || rest < limit) {
+		limit = rest
+	}
+
+	var n int64
+
+	if limit >= 0 {
+		n, err = io.Copy(writer, io.LimitReader(reader, limit+1))
+	} else {
+		n, err = io.Copy(writer, reader)
+	}
+
+	if closeErr := writer.Close(); err == nil {
+		err = closeErr
+	}
+
+	if err != nil {
+		return errors.Wrapf(err, "write file `%s` fail", target)
+	}
+
+	if limit >= 0 && n > limit {
+		return errors.Wrapf(ErrTooLarge, "`%s` exceeds the limit", name)
+	}
+
+	// the umask may drop the permission bits
+	if err := os.Chmod(target, mode); err != nil {
+		return errors.Wrapf(err, "change mode of `%s` fail", target)
+	}
+
+	e.total += n
+	e.files = append(e.files, path.Clean(strings.ReplaceAll(name, "\\", "/")))
+
+	return nil
+}
+
+// create the symlink, it must point to a path inside dest
+func (e *extractor) symlink(name string, linkname string) error {
+	target, err := e.path(name)
+
+	if err != nil {
+		return err
+	}
+
+	if err := e.checkSymlink(name, linkname); err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return errors.Wrapf(err, "create dir `%s` fail", filepath.Dir(target))
+	}
+
+	if err := os.Symlink(linkname, target); err != nil {
+		return errors.Wrapf(err, "create symlink `%s` fail", target)
+	}
+
+	e.links = append(e.links, [2]string{name, linkname})
+
+	return nil
+}
+
+// resolve the link step by step against the extracted tree, it must stay inside dest and must not pass through another symlink,
+// eg `esc -> p/up/..` escapes if `p/up -> ..` is extracted, although it looks like `p` lexically
+func (e *extractor) checkSymlink(name string, linkname string) error {
+	linkname = strings.ReplaceAll(linkname, "\\", "/")
+
+	if linkname == "" || path.IsAbs(linkname) || filepath.IsAbs(linkname) || filepath.VolumeName(linkname) != "" {
+		return errors.Wrapf(ErrUnsafePath, "symlink `%s` to `%s`", name, linkname)
+	}
+
+	parts := make([]string, 0)
+
+	if dir := path.Dir(path.Clean(strings.ReplaceAll(name, "\\", "/"))); dir != "." {
+		parts = strings.Split(dir, "/")
+	}
+
+	for _, part := range strings.Split(linkname, "/") {
+		switch part {
+		case "", ".":
+			continue
+		case "..":
+			if len(parts) == 0 {
+				return errors.Wrapf(ErrUnsafePath, "symlink `%s` to `%s` escapes", name, linkname)
+			}
+
+			parts = parts[:len(parts)-1]
+
+			continue
+		}
+
+		parts = append(parts, part)
+
+		if stat, err := os.Lstat(filepath.Join(e.dest, filepath.Join(parts...))); err == nil && stat.Mode()&os.ModeSymlink != 0 {
+			return errors.Wrapf(ErrUnsafePath, "symlink `%s` to `%s` passes through a symlink", name, linkname)
+		}
+	}
+
+	return nil
+}
+
+// check the symlinks again after all entries are extracted,
+// because a symlink may pass through another one which is extracted after it
+func (e *extractor) checkSymlinks() error {
+	for _, link := range e.links {
+		if err := e.checkSymlink(link[0], link[1]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,329 @@
+package archive
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/axetroy/denox/internal/testutil"
+	"github.com/pkg/errors"
+)
+
+type entry struct {
+	name     string
+	linkname string
+	mode     os.FileMode
+	body     string
+}
+
+func newTar(t *testing.T, entries []entry) []byte {
+	var buf bytes.Buffer
+
+	w := tar.NewWriter(&buf)
+
+	for _, e := range entries {
+		header := &tar.Header{Name: e.name, Mode: int64(e.mode.Perm())}
+
+		switch {
+		case e.mode.IsDir():
+			header.Typeflag = tar.TypeDir
+		case e.mode&os.ModeSymlink != 0:
+			header.Typeflag = tar.TypeSymlink
+			header.Linkname = e.linkname
+		default:
+			header.Typeflag = tar.TypeReg
+			header.Size = int64(len(e.body))
+		}
+
+		if err := w.WriteHeader(header); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := w.Write([]byte(e.body)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	return buf.Bytes()
+}
+
+func newZip(t *testing.T, entries []entry) []byte {
+	var buf bytes.Buffer
+
+	w := zip.NewWriter(&buf)
+
+	for _, e := range entries {
+		header := &zip.FileHeader{Name: e.name, Method: zip.Deflate}
+		header.SetMode(e.mode)
+
+		f, err := w.CreateHeader(header)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		body := e.body
+
+		if e.mode&os.ModeSymlink != 0 {
+			body = e.linkname
+		}
+
+		if _, err := f.Write([]byte(body)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	return buf.Bytes()
+}
+
+// extract the entries in the format with Extract into dir/dest, it returns the dest dir
+func extract(t *testing.T, dir string, format Format, entries []entry, options Options) (string, []string, error) {
+	dest := filepath.Join(dir, "dest")
+
+	var data []byte
+
+	switch format {
+	case FormatZip:
+		data = newZip(t, entries)
+	case FormatTar:
+		data = newTar(t, entries)
+	default:
+		t.Fatalf("unsupported format %s", format)
+	}
+
+	file := filepath.Join(dir, "archive."+string(format))
+
+	if err := ioutil.WriteFile(file, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	files, err := Extract(file, dest, options)
+
+	return dest, files, err
+}
+
+func TestExtractUnsafePath(t *testing.T) {
+	tests := []struct {
+		name    string
+		entries []entry
+	}{
+		{"zip-slip", []entry{{name: "../evil", mode: 0644, body: "evil"}}},
+		{"nested zip-slip", []entry{{name: "a/../../evil", mode: 0644, body: "evil"}}},
+		{"absolute path", []entry{{name: "/tmp/evil", mode: 0644, body: "evil"}}},
+		{"backslash zip-slip", []entry{{name: "..\\evil", mode: 0644, body: "evil"}}},
+		{"absolute symlink", []entry{{name: "link", linkname: "/etc/passwd", mode: os.ModeSymlink | 0777}}},
+		{"parent symlink", []entry{{name: "link", linkname: "..", mode: os.ModeSymlink | 0777}}},
+		{"nested parent symlink", []entry{{name: "a/link", linkname: "../../evil", mode: os.ModeSymlink | 0777}}},
+		{"write through symlink", []entry{
+			{name: "dir", linkname: ".", mode: os.ModeSymlink | 0777},
+			{name: "dir/file", mode: 0644, body: "evil"},
+		}},
+		{"overwrite symlink", []entry{
+			{name: "file", mode: 0644, body: "file"},
+			{name: "link", linkname: "file", mode: os.ModeSymlink | 0777},
+			{name: "link", mode: 0644, body: "evil"},
+		}},
+		{"symlink through symlink", []entry{
+			{name: "p/up", linkname: "..", mode: os.ModeSymlink | 0777},
+			{name: "esc", linkname: "p/up/..", mode: os.ModeSymlink | 0777},
+		}},
+		{"symlink through later symlink", []entry{
+			{name: "esc", linkname: "p/up/..", mode: os.ModeSymlink | 0777},
+			{name: "p/up", linkname: "..", mode: os.ModeSymlink | 0777},
+		}},
+		{"symlink to symlink", []entry{
+			{name: "p/up", linkname: "..", mode: os.ModeSymlink | 0777},
+			{name: "esc", linkname: "p/up", mode: os.ModeSymlink | 0777},
+		}},
+	}
+
+	for _, format := range []Format{FormatTar, FormatZip} {
+		for _, test := range tests {
+			t.Run(string(format)+"/"+test.name, func(t *testing.T) {
+				dir, clean := testutil.TempDir(t)
+				defer clean()
+
+				dest, _, err := extract(t, dir, format, test.entries, DefaultOptions())
+
+				if errors.Cause(err) != ErrUnsafePath {
+					t.Fatalf("expect %v, got %v", ErrUnsafePath, err)
+				}
+
+				if exist, _ := filepath.Glob(filepath.Join(filepath.Dir(dest), "evil")); len(exist) > 0 {
+					t.Fatalf("file is written outside of dest")
+				}
+			})
+		}
+	}
+}
+
+func TestExtractSafeSymlink(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("symlink requires privilege on Windows")
+	}
+
+	entries := []entry{
+		{name: "bin/deno", mode: 0755, body: "deno"},
+		{name: "lib/", mode: os.ModeDir | 0755},
+		{name: "lib/deno", linkname: "../bin/deno", mode: os.ModeSymlink | 0777},
+	}
+
+	for _, format := range []Format{FormatTar, FormatZip} {
+		t.Run(string(format), func(t *testing.T) {
+			dir, clean := testutil.TempDir(t)
+			defer clean()
+
+			dest, _, err := extract(t, dir, format, entries, DefaultOptions())
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			b, err := ioutil.ReadFile(filepath.Join(dest, "lib", "deno"))
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if string(b) != "deno" {
+				t.Fatalf("expect `deno`, got `%s`", b)
+			}
+		})
+	}
+}
+
+func TestExtractSizeLimit(t *testing.T) {
+	tests := []struct {
+		name    string
+		entries []entry
+		options Options
+		err     error
+	}{
+		{
+			name:    "file under limit",
+			entries: []entry{{name: "deno", mode: 0755, body: "12345"}},
+			options: Options{MaxFileSize: 5, MaxTotalSize: 5},
+		},
+		{
+			name:    "file over limit",
+			entries: []entry{{name: "deno", mode: 0755, body: "123456"}},
+			options: Options{MaxFileSize: 5},
+			err:     ErrTooLarge,
+		},
+		{
+			name: "total over limit",
+			entries: []entry{
+				{name: "a", mode: 0644, body: "1234"},
+				{name: "b", mode: 0644, body: "1234"},
+			},
+			options: Options{MaxFileSize: 5, MaxTotalSize: 6},
+			err:     ErrTooLarge,
+		},
+		{
+			name:    "no limit",
+			entries: []entry{{name: "deno", mode: 0755, body: "123456"}},
+			options: Options{},
+		},
+	}
+
+	for _, format := range []Format{FormatTar, FormatZip} {
+		for _, test := range tests {
+			t.Run(string(format)+"/"+test.name, func(t *testing.T) {
+				dir, clean := testutil.TempDir(t)
+				defer clean()
+
+				_, _, err := extract(t, dir, format, test.entries, test.options)
+
+				if errors.Cause(err) != test.err {
+					t.Fatalf("expect %v, got %v", test.err, err)
+				}
+			})
+		}
+	}
+}
+
+func TestExtractMode(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("no permission bits on Windows")
+	}
+
+	entries := []entry{
+		{name: "bin/deno", mode: 0755, body: "deno"},
+		{name: "README.md", mode: 0644, body: "readme"},
+		// the owner can always read and write it
+		{name: "LICENSE", mode: 0444, body: "license"},
+	}
+
+	expected := map[string]os.FileMode{
+		"bin/deno":  0755,
+		"README.md": 0644,
+		"LICENSE":   0644,
+	}
+
+	for _, format := range []Format{FormatTar, FormatZip} {
+		t.Run(string(format), func(t *testing.T) {
+			dir, clean := testutil.TempDir(t)
+			defer clean()
+
+			dest, files, err := extract(t, dir, format, entries, DefaultOptions())
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(files) != len(expected) {
+				t.Fatalf("expect %d files, got %v", len(expected), files)
+			}
+
+			for name, mode := range expected {
+				stat, err := os.Stat(filepath.Join(dest, filepath.FromSlash(name)))
+
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				if stat.Mode().Perm() != mode {
+					t.Fatalf("expect mode %v of `%s`, got %v", mode, name, stat.Mode().Perm())
+				}
+			}
+		})
+	}
+}
+
+func TestFindExecutable(t *testing.T) {
+	tests := []struct {
+		files    []string
+		expected string
+		err      error
+	}{
+		{files: []string{"deno"}, expected: "deno"},
+		{files: []string{"lib/deno", "deno", "README.md"}, expected: "deno"},
+		{files: []string{"deno-x86_64"}, expected: "deno-x86_64"},
+		{files: []string{"README.md", "LICENSE"}, err: ErrExecutableNotFound},
+	}
+
+	for _, test := range tests {
+		actual, err := FindExecutable(test.files, "deno")
+
+		if errors.Cause(err) != test.err {
+			t.Fatalf("expect %v for %v, got %v", test.err, test.files, err)
+		}
+
+		if actual != test.expected {
+			t.Fatalf("expect `%s` for %v, got `%s`", test.expected, test.files, actual)
+		}
+	}
+}
@@ -0,0 +1,22 @@
+package archive
+
+import (
+	"compress/gzip"
+	"io"
+
+	"github.com/pkg/errors"
+)
+
+// a single file compressed with gzip, it is extracted as name
+func (e *extractor) extractGz(reader io.Reader, name string) error {
+	gzipReader, err := gzip.NewReader(reader)
+
+	if err != nil {
+		return errors.Wrap(err, "gzip decode fail")
+	}
+
+	defer gzipReader.Close()
+
+	// the compressed file is always an executable
+	return e.writeFile(name, 0755, gzipReader)
+}
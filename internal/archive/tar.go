@@ -0,0 +1,88 @@
+package archive
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"io"
+	"os"
+
+	"github.com/pkg/errors"
+	"github.com/ulikunitz/xz"
+)
+
+func (e *extractor) extractTarStream(reader io.Reader, format Format) error {
+	switch format {
+	case FormatTarGz:
+		gzipReader, err := gzip.NewReader(reader)
+
+		if err != nil {
+			return errors.Wrap(err, "gzip decode fail")
+		}
+
+		defer gzipReader.Close()
+
+		reader = gzipReader
+	case FormatTarXz:
+		xzReader, err := xz.NewReader(reader)
+
+		if err != nil {
+			return errors.Wrap(err, "xz decode fail")
+		}
+
+		reader = xzReader
+	}
+
+	return e.extractTar(reader)
+}
+
+func (e *extractor) extractTar(reader io.Reader) error {
+	r := tar.NewReader(reader)
+
+	for {
+		header, err := r.Next()
+
+		if err == io.EOF {
+			return nil
+		}
+
+		if err != nil {
+			return errors.Wrap(err, "read tar fail")
+		}
+
+		switch header.Typeflag {
+		case tar.TypeDir:
+			err = e.mkdir(header.Name)
+		case tar.TypeReg, tar.TypeRegA:
+			err = e.writeFile(header.Name, os.FileMode(header.Mode), r)
+		case tar.TypeSymlink:
+			err = e.symlink(header.Name, header.Linkname)
+		case tar.TypeLink:
+			err = e.link(header.Name, header.Linkname, os.FileMode(header.Mode))
+		}
+
+		// skip the devices, pipes and so on
+
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// a hard link is extracted as a copy of the file extracted before
+func (e *extractor) link(name string, linkname string, mode os.FileMode) error {
+	src, err := e.path(linkname)
+
+	if err != nil {
+		return err
+	}
+
+	f, err := os.Open(src)
+
+	if err != nil {
+		return errors.Wrapf(err, "open `%s` fail", linkname)
+	}
+
+	defer f.Close()
+
+	return e.writeFile(name, mode, f)
+}
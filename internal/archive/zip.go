@@ -0,0 +1,82 @@
+package archive
+
+import (
+	"archive/zip"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+func (e *extractor) extractZip(file string) error {
+	r, err := zip.OpenReader(file)
+
+	if err != nil {
+		return errors.Wrapf(err, "read zip file `%s` fail", file)
+	}
+
+	defer r.Close()
+
+	// fail fast with the declared size, the real size is checked while writing
+	var total uint64
+
+	for _, f := range r.File {
+		total += f.UncompressedSize64
+	}
+
+	if e.options.MaxTotalSize > 0 && total > uint64(e.options.MaxTotalSize) {
+		return errors.Wrapf(ErrTooLarge, "`%s` has %d bytes", file, total)
+	}
+
+	for _, f := range r.File {
+		if err := e.extractZipFile(f); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (e *extractor) extractZipFile(f *zip.File) error {
+	mode := f.Mode()
+
+	switch {
+	case mode.IsDir() || strings.HasSuffix(f.Name, "/"):
+		return e.mkdir(f.Name)
+	case mode&os.ModeSymlink != 0:
+		src, err := f.Open()
+
+		if err != nil {
+			return errors.Wrapf(err, "open `%s` fail", f.Name)
+		}
+
+		defer src.Close()
+
+		linkname, err := ioutil.ReadAll(src)
+
+		if err != nil {
+			return errors.Wrapf(err, "read `%s` fail", f.Name)
+		}
+
+		return e.symlink(f.Name, string(linkname))
+	case mode.IsRegular():
+		src, err := f.Open()
+
+		if err != nil {
+			return errors.Wrapf(err, "open `%s` fail", f.Name)
+		}
+
+		defer src.Close()
+
+		// the zip created on Windows has no permission bits
+		if mode.Perm() == 0 {
+			mode = 0644
+		}
+
+		return e.writeFile(f.Name, mode, src)
+	}
+
+	// skip the devices, pipes and so on
+	return nil
+}
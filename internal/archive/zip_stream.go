@@ -0,0 +1,213 @@
+package archive
+
+import (
+	"archive/zip"
+	"bufio"
+	"compress/flate"
+	"encoding/binary"
+	"hash/crc32"
+	"io"
+	"io/ioutil"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	zipLocalHeaderSignature    = 0x04034b50
+	zipCentralHeaderSignature  = 0x02014b50
+	zipEndSignature            = 0x06054b50
+	zipDataDescriptorSignature = 0x08074b50
+	zip64ExtraID               = 0x0001
+
+	// the general purpose flags
+	zipFlagEncrypted      = 0x1
+	zipFlagDataDescriptor = 0x8
+)
+
+// the local file header of zip, without the signature
+type zipLocalHeader struct {
+	ReaderVersion    uint16
+	Flags            uint16
+	Method           uint16
+	ModifiedTime     uint16
+	ModifiedDate     uint16
+	CRC32            uint32
+	CompressedSize   uint32
+	UncompressedSize uint32
+	NameLength       uint16
+	ExtraLength      uint16
+}
+
+// read the zip by the local file headers, it stops at the central directory
+func (e *extractor) extractZipStream(reader io.Reader) error {
+	r := bufio.NewReader(reader)
+
+	for {
+		var signature uint32
+
+		if err := binary.Read(r, binary.LittleEndian, &signature); err != nil {
+			return errors.Wrap(err, "read zip fail")
+		}
+
+		switch signature {
+		case zipLocalHeaderSignature:
+			if err := e.extractZipStreamFile(r); err != nil {
+				return err
+			}
+		case zipCentralHeaderSignature, zipEndSignature:
+			// the central directory has nothing new for us
+			return nil
+		default:
+			return errors.Errorf("read zip fail: invalid signature %#x", signature)
+		}
+	}
+}
+
+func (e *extractor) extractZipStreamFile(r *bufio.Reader) error {
+	var header zipLocalHeader
+
+	if err := binary.Read(r, binary.LittleEndian, &header); err != nil {
+		return errors.Wrap(err, "read zip header fail")
+	}
+
+	b := make([]byte, int(header.NameLength)+int(header.ExtraLength))
+
+	if _, err := io.ReadFull(r, b); err != nil {
+		return errors.Wrap(err, "read zip header fail")
+	}
+
+	name := string(b[:header.NameLength])
+	compressedSize, zip64 := readZip64Size(b[header.NameLength:], header)
+
+	if header.Flags&zipFlagEncrypted != 0 {
+		return errors.Errorf("read zip fail: `%s` is encrypted", name)
+	}
+
+	hasDataDescriptor := header.Flags&zipFlagDataDescriptor != 0
+
+	var (
+		data io.Reader
+		// the compressed data, nil if the size is unknown
+		compressed io.Reader
+	)
+
+	if !hasDataDescriptor {
+		compressed = io.LimitReader(r, int64(compressedSize))
+	}
+
+	switch {
+	case header.Method == zip.Store && compressed != nil:
+		data = compressed
+	case header.Method == zip.Deflate && compressed != nil:
+		data = flate.NewReader(compressed)
+	case header.Method == zip.Deflate:
+		// bufio.Reader is a io.ByteReader, so that flate does not read beyond the compressed data
+		data = flate.NewReader(r)
+	default:
+		return errors.Errorf("read zip fail: unsupported method %d of `%s`", header.Method, name)
+	}
+
+	crc := crc32.NewIEEE()
+	data = io.TeeReader(data, crc)
+
+	var err error
+
+	if strings.HasSuffix(name, "/") {
+		err = e.mkdir(name)
+	} else {
+		// the file modes are in the central directory, which comes at the end
+		err = e.writeFile(name, 0644, data)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	// read the rest of the entry
+	if _, err := io.Copy(ioutil.Discard, data); err != nil {
+		return errors.Wrapf(err, "read `%s` fail", name)
+	}
+
+	if compressed != nil {
+		if _, err := io.Copy(ioutil.Discard, compressed); err != nil {
+			return errors.Wrapf(err, "read `%s` fail", name)
+		}
+	}
+
+	expectedCRC := header.CRC32
+
+	if hasDataDescriptor {
+		if expectedCRC, err = readZipDataDescriptor(r, zip64); err != nil {
+			return err
+		}
+	}
+
+	if crc.Sum32() != expectedCRC {
+		return errors.Errorf("read zip fail: checksum of `%s` mismatch", name)
+	}
+
+	return nil
+}
+
+// get the compressed size from the zip64 extra field if the size in header overflows
+func readZip64Size(extra []byte, header zipLocalHeader) (uint64, bool) {
+	compressedSize := uint64(header.CompressedSize)
+
+	for len(extra) >= 4 {
+		id := binary.LittleEndian.Uint16(extra[0:2])
+		size := int(binary.LittleEndian.Uint16(extra[2:4]))
+
+		if len(extra) < 4+size {
+			break
+		}
+
+		field := extra[4 : 4+size]
+		extra = extra[4+size:]
+
+		if id != zip64ExtraID {
+			continue
+		}
+
+		// the fields appear only if the ones in header are 0xFFFFFFFF, in the order of uncompressed and compressed size
+		if header.UncompressedSize == 0xFFFFFFFF && len(field) >= 8 {
+			field = field[8:]
+		}
+
+		if header.CompressedSize == 0xFFFFFFFF && len(field) >= 8 {
+			compressedSize = binary.LittleEndian.Uint64(field[:8])
+		}
+
+		return compressedSize, true
+	}
+
+	return compressedSize, false
+}
+
+// read the data descriptor after the data, returns the CRC-32 in it
+func readZipDataDescriptor(r *bufio.Reader, zip64 bool) (uint32, error) {
+	// the signature is optional
+	b, err := r.Peek(4)
+
+	if err != nil {
+		return 0, errors.Wrap(err, "read zip data descriptor fail")
+	}
+
+	if binary.LittleEndian.Uint32(b) == zipDataDescriptorSignature {
+		_, _ = r.Discard(4)
+	}
+
+	size := 4 + 4 + 4
+
+	if zip64 {
+		size = 4 + 8 + 8
+	}
+
+	descriptor := make([]byte, size)
+
+	if _, err := io.ReadFull(r, descriptor); err != nil {
+		return 0, errors.Wrap(err, "read zip data descriptor fail")
+	}
+
+	return binary.LittleEndian.Uint32(descriptor[:4]), nil
+}
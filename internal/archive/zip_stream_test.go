@@ -0,0 +1,207 @@
+package archive
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/flate"
+	"encoding/binary"
+	"hash/crc32"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/axetroy/denox/internal/testutil"
+	"github.com/pkg/errors"
+)
+
+// create a zip with zip.Writer, the sizes are in the data descriptors after the data
+func newZipStream(t *testing.T, method uint16, files map[string]string) []byte {
+	var buf bytes.Buffer
+
+	z := zip.NewWriter(&buf)
+
+	for name, body := range files {
+		f, err := z.CreateHeader(&zip.FileHeader{Name: name, Method: method})
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := f.Write([]byte(body)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := z.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	return buf.Bytes()
+}
+
+// create a zip without data descriptor, the sizes are in the local file header.
+// zip.Writer always writes the data descriptor, so it is written by hand
+func newZipStreamWithoutDescriptor(t *testing.T, method uint16, name string, body string) []byte {
+	var data bytes.Buffer
+
+	if method == zip.Deflate {
+		fw, err := flate.NewWriter(&data, flate.DefaultCompression)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		_, _ = fw.Write([]byte(body))
+		_ = fw.Close()
+	} else {
+		data.WriteString(body)
+	}
+
+	var buf bytes.Buffer
+
+	_ = binary.Write(&buf, binary.LittleEndian, uint32(zipLocalHeaderSignature))
+	_ = binary.Write(&buf, binary.LittleEndian, zipLocalHeader{
+		ReaderVersion:    20,
+		Method:           method,
+		CRC32:            crc32.ChecksumIEEE([]byte(body)),
+		CompressedSize:   uint32(data.Len()),
+		UncompressedSize: uint32(len(body)),
+		NameLength:       uint16(len(name)),
+	})
+	buf.WriteString(name)
+	buf.Write(data.Bytes())
+
+	// the end of central directory, without any entry
+	_ = binary.Write(&buf, binary.LittleEndian, uint32(zipEndSignature))
+	buf.Write(make([]byte, 18))
+
+	return buf.Bytes()
+}
+
+// extract the zip into `dest` of a temporary dir, call clean to remove the dir
+func extractZipStream(t *testing.T, data []byte, options Options) (dest string, files []string, clean func(), err error) {
+	dir, clean := testutil.TempDir(t)
+	dest = filepath.Join(dir, "dest")
+
+	files, err = ExtractReader(bytes.NewReader(data), "deno.zip", dest, options)
+
+	return dest, files, clean, err
+}
+
+func TestExtractZipStream(t *testing.T) {
+	body := strings.Repeat("deno ", 1000)
+
+	tests := []struct {
+		name string
+		data func(t *testing.T) []byte
+	}{
+		{"deflate with data descriptor", func(t *testing.T) []byte {
+			return newZipStream(t, zip.Deflate, map[string]string{"deno": body})
+		}},
+		{"deflate without data descriptor", func(t *testing.T) []byte {
+			return newZipStreamWithoutDescriptor(t, zip.Deflate, "deno", body)
+		}},
+		{"store without data descriptor", func(t *testing.T) []byte {
+			return newZipStreamWithoutDescriptor(t, zip.Store, "deno", body)
+		}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			dest, files, clean, err := extractZipStream(t, test.data(t), DefaultOptions())
+
+			defer clean()
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(files) != 1 || files[0] != "deno" {
+				t.Fatalf("expect [deno], got %v", files)
+			}
+
+			b, err := ioutil.ReadFile(filepath.Join(dest, "deno"))
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if string(b) != body {
+				t.Fatalf("expect %d bytes, got %d bytes which are different", len(body), len(b))
+			}
+		})
+	}
+}
+
+func TestExtractZipStreamMultipleFiles(t *testing.T) {
+	files := map[string]string{
+		"deno":        "deno",
+		"lib/":        "",
+		"lib/LICENSE": "license",
+	}
+
+	dest, extracted, clean, err := extractZipStream(t, newZipStream(t, zip.Deflate, files), DefaultOptions())
+
+	defer clean()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(extracted) != 2 {
+		t.Fatalf("expect 2 files, got %v", extracted)
+	}
+
+	for name, body := range files {
+		if strings.HasSuffix(name, "/") {
+			continue
+		}
+
+		b, err := ioutil.ReadFile(filepath.Join(dest, filepath.FromSlash(name)))
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if string(b) != body {
+			t.Fatalf("expect `%s` of %s, got `%s`", body, name, b)
+		}
+	}
+}
+
+func TestExtractZipStreamInvalid(t *testing.T) {
+	valid := newZipStreamWithoutDescriptor(t, zip.Store, "deno", "deno")
+
+	// flip a byte of the data, it is right after the header and the name
+	corrupt := append([]byte(nil), valid...)
+	corrupt[30+len("deno")] ^= 0xff
+
+	tests := []struct {
+		name string
+		data []byte
+		err  error
+	}{
+		{name: "checksum mismatch", data: corrupt},
+		{name: "truncated", data: valid[:20]},
+		{name: "not zip", data: []byte("this is not a zip file")},
+		{name: "zip-slip", data: newZipStream(t, zip.Deflate, map[string]string{"../evil": "evil"}), err: ErrUnsafePath},
+		{name: "too large", data: newZipStream(t, zip.Deflate, map[string]string{"deno": strings.Repeat("0", 100)}), err: ErrTooLarge},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, _, clean, err := extractZipStream(t, test.data, Options{MaxFileSize: 50})
+
+			defer clean()
+
+			if err == nil {
+				t.Fatal("expect error, got nil")
+			}
+
+			if test.err != nil && errors.Cause(err) != test.err {
+				t.Fatalf("expect %v, got %v", test.err, err)
+			}
+		})
+	}
+}
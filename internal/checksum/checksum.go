@@ -0,0 +1,142 @@
+package checksum
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"hash"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+var (
+	ErrMismatch = errors.New("checksum mismatch")
+)
+
+var sha256Regexp = regexp.MustCompile(`^[0-9a-fA-F]{64}$`)
+
+// Sums is the SHA-256 checksums indexed by file name
+type Sums map[string]string
+
+// Parse the checksum list in the format of `sha256sum`, eg `<hash>  <name>`.
+// the output of PowerShell `Get-FileHash` is also supported.
+// a line with only the hash is stored with empty name
+func Parse(r io.Reader) (Sums, error) {
+	sums := Sums{}
+
+	scanner := bufio.NewScanner(r)
+
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+
+		for i, field := range fields {
+			if !sha256Regexp.MatchString(field) {
+				continue
+			}
+
+			name := ""
+
+			if i+1 < len(fields) {
+				name = fields[len(fields)-1]
+				// binary mode of sha256sum
+				name = strings.TrimPrefix(name, "*")
+				// PowerShell prints the full path
+				if j := strings.LastIndex(name, `\`); j >= 0 {
+					name = name[j+1:]
+				}
+			}
+
+			sums[name] = strings.ToLower(field)
+
+			break
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, errors.Wrap(err, "read checksum fail")
+	}
+
+	return sums, nil
+}
+
+// ParseFile parse the checksum file
+func ParseFile(file string) (Sums, error) {
+	f, err := os.Open(file)
+
+	if err != nil {
+		return nil, errors.Wrapf(err, "open file `%s` fail", file)
+	}
+
+	defer f.Close()
+
+	return Parse(f)
+}
+
+// File returns the SHA-256 checksum of the file in hex
+func File(file string) (string, error) {
+	f, err := os.Open(file)
+
+	if err != nil {
+		return "", errors.Wrapf(err, "open file `%s` fail", file)
+	}
+
+	defer f.Close()
+
+	h := sha256.New()
+
+	if _, err := io.Copy(h, f); err != nil {
+		return "", errors.Wrapf(err, "read file `%s` fail", file)
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// Verify the SHA-256 checksum of the file
+func Verify(file string, expected string) error {
+	actual, err := File(file)
+
+	if err != nil {
+		return err
+	}
+
+	if !strings.EqualFold(actual, expected) {
+		return errors.Wrapf(ErrMismatch, "`%s` expected %s but got %s", file, strings.ToLower(expected), actual)
+	}
+
+	return nil
+}
+
+// Reader computes the SHA-256 checksum of the data read through it
+type Reader struct {
+	reader io.Reader
+	hash   hash.Hash
+}
+
+// NewReader create a Reader reads from r
+func NewReader(r io.Reader) *Reader {
+	h := sha256.New()
+
+	return &Reader{reader: io.TeeReader(r, h), hash: h}
+}
+
+func (r *Reader) Read(p []byte) (int, error) {
+	return r.reader.Read(p)
+}
+
+// Sum returns the SHA-256 checksum in hex of the data read so far
+func (r *Reader) Sum() string {
+	return hex.EncodeToString(r.hash.Sum(nil))
+}
+
+// Verify the checksum of the data read so far
+func (r *Reader) Verify(name string, expected string) error {
+	if actual := r.Sum(); !strings.EqualFold(actual, expected) {
+		return errors.Wrapf(ErrMismatch, "`%s` expected %s but got %s", name, strings.ToLower(expected), actual)
+	}
+
+	return nil
+}
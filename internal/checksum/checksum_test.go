@@ -0,0 +1,121 @@
+package checksum
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	sumA = "7cd1fa300f817507ee5503618c509316ea4f82433fa75b90acfd43a0b1597d83"
+	sumB = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected Sums
+	}{
+		{
+			name:     "sha256sum",
+			input:    sumA + "  deno-x86_64-unknown-linux-gnu.zip\n" + sumB + "  deno-aarch64-apple-darwin.zip\n",
+			expected: Sums{"deno-x86_64-unknown-linux-gnu.zip": sumA, "deno-aarch64-apple-darwin.zip": sumB},
+		},
+		{
+			name:     "binary mode",
+			input:    sumA + " *deno-x86_64-pc-windows-msvc.zip",
+			expected: Sums{"deno-x86_64-pc-windows-msvc.zip": sumA},
+		},
+		{
+			name:     "with version",
+			input:    sumA + "  v1.4.2/deno-x86_64-unknown-linux-gnu.zip",
+			expected: Sums{"v1.4.2/deno-x86_64-unknown-linux-gnu.zip": sumA},
+		},
+		{
+			name:     "upper case",
+			input:    strings.ToUpper(sumA) + "  deno.zip",
+			expected: Sums{"deno.zip": sumA},
+		},
+		{
+			name:     "hash only",
+			input:    sumA + "\n",
+			expected: Sums{"": sumA},
+		},
+		{
+			name: "PowerShell Get-FileHash",
+			input: "Algorithm       Hash                                                                   Path\n" +
+				"---------       ----                                                                   ----\n" +
+				"SHA256          " + strings.ToUpper(sumA) + "       C:\\Users\\deno\\deno-x86_64-pc-windows-msvc.zip\n",
+			expected: Sums{"deno-x86_64-pc-windows-msvc.zip": sumA},
+		},
+		{
+			name:     "blank lines and garbage",
+			input:    "\n# comment\nnot a hash  deno.zip\n" + sumA[:63] + "  short.zip\n" + sumB + "  deno.zip\n",
+			expected: Sums{"deno.zip": sumB},
+		},
+		{
+			name:     "empty",
+			input:    "",
+			expected: Sums{},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			actual, err := Parse(strings.NewReader(test.input))
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !reflect.DeepEqual(actual, test.expected) {
+				t.Fatalf("expect %v, got %v", test.expected, actual)
+			}
+		})
+	}
+}
+
+func TestVerify(t *testing.T) {
+	dir, err := ioutil.TempDir("", "denox-checksum-test")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	// sumB is the checksum of the empty data
+	file := filepath.Join(dir, "empty")
+
+	if err := ioutil.WriteFile(file, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := Verify(file, strings.ToUpper(sumB)); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := Verify(file, sumA); errors.Cause(err) != ErrMismatch {
+		t.Fatalf("expect %v, got %v", ErrMismatch, err)
+	}
+
+	r := NewReader(strings.NewReader(""))
+
+	if _, err := ioutil.ReadAll(r); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := r.Verify("empty", sumB); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := r.Verify("empty", sumA); errors.Cause(err) != ErrMismatch {
+		t.Fatalf("expect %v, got %v", ErrMismatch, err)
+	}
+}
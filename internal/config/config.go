@@ -0,0 +1,225 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/axetroy/denox/internal/dirs"
+	"github.com/axetroy/denox/internal/fs"
+	"github.com/axetroy/denox/internal/logger"
+	"github.com/pkg/errors"
+)
+
+const (
+	// the project config, it is also the pin file of the version
+	ProjectFilename = ".denoxrc"
+)
+
+var (
+	ErrUnknownKey = errors.New("unknown config key")
+)
+
+// Key is a config item, it can be overridden by the environment variable
+type Key struct {
+	Name        string
+	Env         string
+	Description string
+}
+
+// Keys are the known config items
+var Keys = []Key{
+	{Name: "version", Env: "DENO_VERSION", Description: "the default version range of Deno"},
+	{Name: "mirror", Env: "DENOX_MIRROR", Description: "comma separated download mirrors"},
+	{Name: "proxy", Env: "DENOX_PROXY", Description: "the proxy URL to download Deno"},
+	{Name: "deno_dir_policy", Env: "DENOX_DENO_DIR_POLICY", Description: "`per-version`, `shared` or `ephemeral`"},
+	{Name: "progress", Env: "DENOX_PROGRESS", Description: "`bar`, `plain`, `json` or `none`"},
+	{Name: "deno_flags", Env: "DENOX_DENO_FLAGS", Description: "space separated flags passed to Deno, eg `--unstable`"},
+}
+
+// Layer is where the config comes from, the latter overrides the former
+type Layer string
+
+const (
+	LayerSystem  Layer = "system"
+	LayerUser    Layer = "user"
+	LayerProject Layer = "project"
+	LayerEnv     Layer = "env"
+)
+
+// File is a config file with `key=value` lines
+type File struct {
+	Layer Layer
+	Path  string
+	// it is empty if the file does not exist
+	Values map[string]string
+}
+
+// Value of a key and where it comes from
+type Value struct {
+	Key   string
+	Value string
+	Layer Layer
+	// the file or the environment variable
+	Source string
+}
+
+// Origin describes where the value comes from
+func (v Value) Origin() string {
+	if v.Layer == LayerEnv {
+		return "environment variable " + v.Source
+	}
+
+	return string(v.Layer) + " config `" + v.Source + "`"
+}
+
+// Config is the merged config of the layers
+type Config struct {
+	// in order of precedence from low to high, except the environment variables
+	Files []*File
+}
+
+var (
+	defaultConfig *Config
+	defaultOnce   sync.Once
+)
+
+// Default returns the config loaded from the current working dir
+func Default() *Config {
+	defaultOnce.Do(func() {
+		cwd, err := os.Getwd()
+
+		if err != nil {
+			logger.Warnf("get current working dir fail: %s", err)
+			cwd = ""
+		}
+
+		defaultConfig = Load(cwd)
+	})
+
+	return defaultConfig
+}
+
+// Load the system config, the user config and the nearest project config from cwd.
+// the file which can not be read is ignored with a warning
+func Load(cwd string) *Config {
+	c := &Config{}
+
+	for _, layer := range []Layer{LayerSystem, LayerUser, LayerProject} {
+		file, err := GetFilePath(layer, cwd)
+
+		if err != nil {
+			logger.Warnf("%s", err)
+			continue
+		}
+
+		if file == "" {
+			continue
+		}
+
+		values, err := ReadFile(file)
+
+		if err != nil {
+			logger.Warnf("read %s config `%s` fail: %s", layer, file, err)
+			continue
+		}
+
+		c.Files = append(c.Files, &File{Layer: layer, Path: file, Values: values})
+	}
+
+	return c
+}
+
+// GetFilePath returns the config file of the layer.
+// the project config is the nearest `.denoxrc` walking up from cwd, it is empty if not found
+func GetFilePath(layer Layer, cwd string) (string, error) {
+	switch layer {
+	case LayerSystem:
+		if runtime.GOOS == "windows" {
+			programData := os.Getenv("ProgramData")
+
+			if programData == "" {
+				programData = `C:\ProgramData`
+			}
+
+			return filepath.Join(programData, "denox", "config"), nil
+		}
+
+		return "/etc/denox/config", nil
+	case LayerUser:
+		layout, err := dirs.Get()
+
+		if err != nil {
+			return "", err
+		}
+
+		return filepath.Join(layout.Config, "config"), nil
+	case LayerProject:
+		if cwd == "" {
+			return "", nil
+		}
+
+		file, err := fs.FindUp(cwd, ProjectFilename)
+
+		if err != nil {
+			return "", errors.Wrap(err, "find project config fail")
+		}
+
+		return file, nil
+	default:
+		return "", errors.Errorf("unknown config layer `%s`", layer)
+	}
+}
+
+// GetKey returns the known key of the name
+func GetKey(name string) (Key, error) {
+	for _, key := range Keys {
+		if key.Name == name {
+			return key, nil
+		}
+	}
+
+	names := make([]string, 0, len(Keys))
+
+	for _, key := range Keys {
+		names = append(names, key.Name)
+	}
+
+	sort.Strings(names)
+
+	return Key{}, errors.Wrapf(ErrUnknownKey, "`%s`, expect one of %s", name, strings.Join(names, ", "))
+}
+
+// Lookup the effective value of the key, the environment variable has the highest precedence.
+// it returns false if the value is not set or empty
+func (c *Config) Lookup(name string) (Value, bool) {
+	key, err := GetKey(name)
+
+	if err != nil {
+		return Value{}, false
+	}
+
+	if v := strings.TrimSpace(os.Getenv(key.Env)); v != "" {
+		return Value{Key: name, Value: v, Layer: LayerEnv, Source: key.Env}, true
+	}
+
+	for i := len(c.Files) - 1; i >= 0; i-- {
+		file := c.Files[i]
+
+		if v := file.Values[name]; v != "" {
+			return Value{Key: name, Value: v, Layer: file.Layer, Source: file.Path}, true
+		}
+	}
+
+	return Value{}, false
+}
+
+// Get the effective value of the key, empty if not set
+func (c *Config) Get(name string) string {
+	v, _ := c.Lookup(name)
+
+	return v.Value
+}
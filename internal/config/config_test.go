@@ -0,0 +1,124 @@
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/axetroy/denox/internal/testutil"
+	"github.com/pkg/errors"
+)
+
+func TestLookupPrecedence(t *testing.T) {
+	defer testutil.Setenv(t, map[string]string{"DENO_VERSION": "", "DENOX_PROGRESS": "", "DENOX_MIRROR": "", "DENOX_PROXY": ""})()
+
+	c := &Config{
+		Files: []*File{
+			{Layer: LayerSystem, Path: "/etc/denox/config", Values: map[string]string{"version": "1.x", "progress": "plain", "mirror": "https://system", "proxy": "http://system"}},
+			{Layer: LayerUser, Path: "/home/denox/config", Values: map[string]string{"version": "2.x", "progress": "json"}},
+			{Layer: LayerProject, Path: "/project/.denoxrc", Values: map[string]string{"version": "3.x"}},
+		},
+	}
+
+	tests := []struct {
+		key    string
+		env    string
+		value  string
+		layer  Layer
+		source string
+	}{
+		{key: "version", value: "3.x", layer: LayerProject, source: "/project/.denoxrc"},
+		{key: "progress", value: "json", layer: LayerUser, source: "/home/denox/config"},
+		{key: "mirror", value: "https://system", layer: LayerSystem, source: "/etc/denox/config"},
+		{key: "version", env: "4.x", value: "4.x", layer: LayerEnv, source: "DENO_VERSION"},
+		{key: "proxy", env: "http://env", value: "http://env", layer: LayerEnv, source: "DENOX_PROXY"},
+		// the blank environment variable is not set
+		{key: "progress", env: "  ", value: "json", layer: LayerUser, source: "/home/denox/config"},
+	}
+
+	for _, test := range tests {
+		key, err := GetKey(test.key)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		restore := testutil.Setenv(t, map[string]string{key.Env: test.env})
+
+		v, ok := c.Lookup(test.key)
+
+		restore()
+
+		if !ok {
+			t.Fatalf("expect `%s` is set", test.key)
+		}
+
+		if v.Value != test.value || v.Layer != test.layer || v.Source != test.source {
+			t.Fatalf("expect `%s` is `%s` from %s `%s`, got `%s` from %s `%s`", test.key, test.value, test.layer, test.source, v.Value, v.Layer, v.Source)
+		}
+	}
+
+	if v, ok := c.Lookup("deno_dir_policy"); ok {
+		t.Fatalf("expect `deno_dir_policy` is not set, got %v", v)
+	}
+
+	if v, ok := c.Lookup("unknown"); ok {
+		t.Fatalf("expect unknown key is not set, got %v", v)
+	}
+}
+
+func TestLoad(t *testing.T) {
+	dir, clean := testutil.TempDir(t)
+	defer clean()
+
+	home := filepath.Join(dir, "home")
+	project := filepath.Join(dir, "project")
+	cwd := filepath.Join(project, "src", "lib")
+
+	defer testutil.Setenv(t, map[string]string{"DENOX_HOME": home, "DENO_VERSION": "", "DENOX_PROGRESS": ""})()
+
+	if err := os.MkdirAll(cwd, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := SetValue(filepath.Join(home, "config"), "progress", "json"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := SetValue(filepath.Join(home, "config"), "version", "1.x"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := ioutil.WriteFile(filepath.Join(project, ProjectFilename), []byte("version=^1.4\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	c := Load(cwd)
+
+	if v, _ := c.Lookup("version"); v.Value != "^1.4" || v.Layer != LayerProject {
+		t.Fatalf("expect version from the project config found from cwd, got %v", v)
+	}
+
+	if v, _ := c.Lookup("progress"); v.Value != "json" || v.Layer != LayerUser {
+		t.Fatalf("expect progress from the user config, got %v", v)
+	}
+
+	if file, err := GetFilePath(LayerProject, cwd); err != nil || file != filepath.Join(project, ProjectFilename) {
+		t.Fatalf("expect the nearest project config, got `%s` and %v", file, err)
+	}
+
+	if file, err := GetFilePath(LayerProject, dir); err != nil || file != "" {
+		t.Fatalf("expect no project config, got `%s` and %v", file, err)
+	}
+}
+
+func TestGetKey(t *testing.T) {
+	if key, err := GetKey("version"); err != nil || key.Env != "DENO_VERSION" {
+		t.Fatalf("expect key `version` of DENO_VERSION, got %v and %v", key, err)
+	}
+
+	if _, err := GetKey("versions"); errors.Cause(err) != ErrUnknownKey {
+		t.Fatalf("expect %v, got %v", ErrUnknownKey, err)
+	}
+}
@@ -0,0 +1,104 @@
+package config
+
+import (
+	"bufio"
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/axetroy/denox/internal/fs"
+	"github.com/pkg/errors"
+)
+
+// ReadFile read the `key=value` lines of the file, the lines start with `#` are comments.
+// it returns an empty map if the file does not exist
+func ReadFile(file string) (map[string]string, error) {
+	values := make(map[string]string)
+
+	b, err := ioutil.ReadFile(file)
+
+	if os.IsNotExist(err) {
+		return values, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(b))
+
+	for scanner.Scan() {
+		if key, value, ok := parseLine(scanner.Text()); ok {
+			// the first one wins, the same as reading the `version` of the pin file
+			if _, exist := values[key]; !exist && value != "" {
+				values[key] = value
+			}
+		}
+	}
+
+	return values, scanner.Err()
+}
+
+// SetValue set the key of the file, the other lines and comments are kept.
+// the key is removed if the value is empty
+func SetValue(file string, key string, value string) error {
+	b, err := ioutil.ReadFile(file)
+
+	if err != nil && !os.IsNotExist(err) {
+		return errors.Wrapf(err, "read file `%s` fail", file)
+	}
+
+	lines := make([]string, 0)
+	written := false
+
+	if len(b) > 0 {
+		lines = strings.Split(strings.TrimRight(string(b), "\n"), "\n")
+	}
+
+	result := make([]string, 0, len(lines)+1)
+
+	for _, line := range lines {
+		if k, _, ok := parseLine(line); ok && k == key {
+			if value != "" && !written {
+				result = append(result, key+"="+value)
+				written = true
+			}
+
+			continue
+		}
+
+		result = append(result, line)
+	}
+
+	if value != "" && !written {
+		result = append(result, key+"="+value)
+	}
+
+	if err := fs.EnsureDir(filepath.Dir(file)); err != nil {
+		return errors.Wrapf(err, "ensure dir `%s` fail", filepath.Dir(file))
+	}
+
+	content := strings.Join(result, "\n")
+
+	if content != "" {
+		content += "\n"
+	}
+
+	return fs.WriteFileAtomic(file, []byte(content))
+}
+
+func parseLine(line string) (key string, value string, ok bool) {
+	line = strings.TrimSpace(line)
+
+	if line == "" || strings.HasPrefix(line, "#") {
+		return "", "", false
+	}
+
+	parts := strings.SplitN(line, "=", 2)
+
+	if len(parts) != 2 {
+		return "", "", false
+	}
+
+	return strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1]), true
+}
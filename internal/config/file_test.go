@@ -0,0 +1,167 @@
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/axetroy/denox/internal/testutil"
+)
+
+func TestReadFile(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		expected map[string]string
+	}{
+		{
+			name:     "key and value",
+			content:  "version=^1.4\nmirror=https://example.com/deno\n",
+			expected: map[string]string{"version": "^1.4", "mirror": "https://example.com/deno"},
+		},
+		{
+			name:     "spaces and CRLF",
+			content:  "  version = ^1.4  \r\n\tprogress=json\r\n",
+			expected: map[string]string{"version": "^1.4", "progress": "json"},
+		},
+		{
+			name:     "comments and blank lines",
+			content:  "# the version\n\nversion=1.x\n  # progress=json\n",
+			expected: map[string]string{"version": "1.x"},
+		},
+		{
+			name:     "the first one wins",
+			content:  "version=1.x\nversion=2.x\n",
+			expected: map[string]string{"version": "1.x"},
+		},
+		{
+			name:     "empty value is not set",
+			content:  "version=\nversion=1.x\nprogress=\n",
+			expected: map[string]string{"version": "1.x"},
+		},
+		{
+			name:     "the value contains =",
+			content:  "deno_flags=--allow-env=HOME --unstable\n",
+			expected: map[string]string{"deno_flags": "--allow-env=HOME --unstable"},
+		},
+		{
+			name:     "line without =",
+			content:  "v1.4.2\nprogress=none\n",
+			expected: map[string]string{"progress": "none"},
+		},
+	}
+
+	dir, clean := testutil.TempDir(t)
+	defer clean()
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			file := filepath.Join(dir, "config")
+
+			if err := ioutil.WriteFile(file, []byte(test.content), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			actual, err := ReadFile(file)
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !reflect.DeepEqual(actual, test.expected) {
+				t.Fatalf("expect %v, got %v", test.expected, actual)
+			}
+		})
+	}
+
+	values, err := ReadFile(filepath.Join(dir, "not-exist"))
+
+	if err != nil || len(values) != 0 {
+		t.Fatalf("expect empty values for the file which does not exist, got %v and %v", values, err)
+	}
+}
+
+func TestSetValue(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		key      string
+		value    string
+		expected string
+	}{
+		{
+			name:     "create the file",
+			key:      "version",
+			value:    "^1.4",
+			expected: "version=^1.4\n",
+		},
+		{
+			name:     "append the key",
+			content:  "# denox\nversion=^1.4\n",
+			key:      "progress",
+			value:    "json",
+			expected: "# denox\nversion=^1.4\nprogress=json\n",
+		},
+		{
+			name:     "replace the key in place",
+			content:  "# denox\nversion=^1.4\nprogress=json\n",
+			key:      "version",
+			value:    "2.x",
+			expected: "# denox\nversion=2.x\nprogress=json\n",
+		},
+		{
+			name:     "replace the duplicated keys",
+			content:  "version=^1.4\nprogress=json\nversion=2.x\n",
+			key:      "version",
+			value:    "3.x",
+			expected: "version=3.x\nprogress=json\n",
+		},
+		{
+			name:     "remove the key",
+			content:  "version=^1.4\n# keep\nprogress=json\n",
+			key:      "version",
+			expected: "# keep\nprogress=json\n",
+		},
+		{
+			name:     "remove the last key",
+			content:  "version=^1.4",
+			key:      "version",
+			expected: "",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			dir, clean := testutil.TempDir(t)
+			defer clean()
+
+			file := filepath.Join(dir, "denox", "config")
+
+			if test.content != "" {
+				if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+					t.Fatal(err)
+				}
+
+				if err := ioutil.WriteFile(file, []byte(test.content), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			if err := SetValue(file, test.key, test.value); err != nil {
+				t.Fatal(err)
+			}
+
+			b, err := ioutil.ReadFile(file)
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if string(b) != test.expected {
+				t.Fatalf("expect %q, got %q", test.expected, b)
+			}
+		})
+	}
+}
@@ -0,0 +1,147 @@
+package deno
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/axetroy/denox/internal/semver"
+	"github.com/pkg/errors"
+)
+
+const (
+	// since this version, the assets are named with the target triple, eg `deno-x86_64-unknown-linux-gnu.zip`
+	// https://github.com/denoland/deno/releases/tag/v0.36.0
+	targetAssetSince = "v0.36.0"
+)
+
+var (
+	ErrAssetNotFound = errors.New("release asset not found")
+)
+
+// target of the release
+type target struct {
+	// target triple
+	triple string
+	// the first version which provides the binary of the target
+	since string
+}
+
+// the targets of each platform `<os>/<arch>`
+var targets = map[string]target{
+	"linux/x64":   {triple: "x86_64-unknown-linux-gnu", since: "v0.0.0"},
+	"osx/x64":     {triple: "x86_64-apple-darwin", since: "v0.0.0"},
+	"win/x64":     {triple: "x86_64-pc-windows-msvc", since: "v0.0.0"},
+	"osx/arm64":   {triple: "aarch64-apple-darwin", since: "v1.6.0"},
+	"linux/arm64": {triple: "aarch64-unknown-linux-gnu", since: "v1.41.0"},
+}
+
+// the asset names before v0.36.0
+var legacyAssets = map[string]string{
+	"linux/x64": "deno_linux_x64.gz",
+	"osx/x64":   "deno_osx_x64.gz",
+	"win/x64":   "deno_win_x64.zip",
+}
+
+func platform(denoOs Os, denoArch Arch) string {
+	return fmt.Sprintf("%s/%s", denoOs, denoArch)
+}
+
+// get the asset name of the version from the naming table
+func getAssetNameFromTable(v *semver.Version, p string) (string, bool) {
+	since, _ := semver.Parse(targetAssetSince)
+
+	if v.LessThan(since) {
+		name, ok := legacyAssets[p]
+		return name, ok
+	}
+
+	t, ok := targets[p]
+
+	if !ok {
+		return "", false
+	}
+
+	if tSince, _ := semver.Parse(t.since); v.LessThan(tSince) {
+		return "", false
+	}
+
+	return "deno-" + t.triple + ".zip", true
+}
+
+// get the platforms which have binary for the version
+func getSupportedPlatforms(v *semver.Version) []string {
+	platforms := make([]string, 0)
+
+	for p := range targets {
+		if _, ok := getAssetNameFromTable(v, p); ok {
+			platforms = append(platforms, p)
+		}
+	}
+
+	sort.Strings(platforms)
+
+	return platforms
+}
+
+// get the asset name of the release for the platform.
+// if the release is known and it does not contain the expected asset, pick one from its asset list
+func getAssetName(version string, denoOs Os, denoArch Arch, release *Release) (string, error) {
+	p := platform(denoOs, denoArch)
+
+	v, err := semver.Parse(version)
+
+	// a version which is not semver, use the newest naming
+	if err != nil {
+		v = &semver.Version{Major: 1 << 30}
+	}
+
+	name, ok := getAssetNameFromTable(v, p)
+
+	if release == nil || len(release.Assets) == 0 {
+		if !ok {
+			return "", errors.Wrapf(ErrNotSupport, "Deno %s does not provide a binary for %s, supported targets: %s", version, p, strings.Join(getSupportedPlatforms(v), ", "))
+		}
+
+		return name, nil
+	}
+
+	if ok && release.hasAsset(name) {
+		return name, nil
+	}
+
+	// the names in every naming are the candidates
+	candidates := make([]string, 0)
+
+	if legacy, ok := legacyAssets[p]; ok {
+		candidates = append(candidates, legacy)
+	}
+
+	if t, ok := targets[p]; ok {
+		candidates = append(candidates, "deno-"+t.triple+".zip")
+	}
+
+	for _, candidate := range candidates {
+		if release.hasAsset(candidate) {
+			return candidate, nil
+		}
+	}
+
+	assets := make([]string, 0)
+
+	for _, asset := range release.Assets {
+		assets = append(assets, asset.Name)
+	}
+
+	return "", errors.Wrapf(ErrNotSupport, "Deno %s does not provide a binary for %s, assets: %s", version, p, strings.Join(assets, ", "))
+}
+
+func (r *Release) hasAsset(name string) bool {
+	for _, asset := range r.Assets {
+		if asset.Name == name {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,140 @@
+package deno
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/axetroy/denox/internal/testutil"
+	"github.com/pkg/errors"
+)
+
+func newRelease(tag string, assets ...string) *Release {
+	release := &Release{Tag: tag}
+
+	for _, name := range assets {
+		release.Assets = append(release.Assets, Asset{Name: name})
+	}
+
+	return release
+}
+
+func TestGetAssetName(t *testing.T) {
+	tests := []struct {
+		version  string
+		os       Os
+		arch     Arch
+		release  *Release
+		expected string
+		err      error
+	}{
+		// the legacy names before v0.36.0
+		{version: "v0.26.0", os: OsLinux, arch: Archx64, expected: "deno_linux_x64.gz"},
+		{version: "v0.35.0", os: OsOsx, arch: Archx64, expected: "deno_osx_x64.gz"},
+		{version: "v0.35.0", os: OsWindows, arch: Archx64, expected: "deno_win_x64.zip"},
+		// the target triples since v0.36.0
+		{version: "v0.36.0", os: OsLinux, arch: Archx64, expected: "deno-x86_64-unknown-linux-gnu.zip"},
+		{version: "v1.4.2", os: OsOsx, arch: Archx64, expected: "deno-x86_64-apple-darwin.zip"},
+		{version: "v1.4.2", os: OsWindows, arch: Archx64, expected: "deno-x86_64-pc-windows-msvc.zip"},
+		{version: "v1.6.0", os: OsOsx, arch: Archarm64, expected: "deno-aarch64-apple-darwin.zip"},
+		{version: "v1.41.0", os: OsLinux, arch: Archarm64, expected: "deno-aarch64-unknown-linux-gnu.zip"},
+		// a version which is not semver uses the newest naming
+		{version: "canary", os: OsLinux, arch: Archx64, expected: "deno-x86_64-unknown-linux-gnu.zip"},
+		// no binary for the platform
+		{version: "v0.35.0", os: OsOsx, arch: Archarm64, err: ErrNotSupport},
+		{version: "v1.5.0", os: OsOsx, arch: Archarm64, err: ErrNotSupport},
+		{version: "v1.40.0", os: OsLinux, arch: Archarm64, err: ErrNotSupport},
+		// the release contains the asset of the table
+		{version: "v1.4.2", os: OsLinux, arch: Archx64, release: newRelease("v1.4.2", "deno-x86_64-unknown-linux-gnu.zip"), expected: "deno-x86_64-unknown-linux-gnu.zip"},
+		// the release uses the other naming, eg the naming changed in a different version than the table says
+		{version: "v0.36.0", os: OsLinux, arch: Archx64, release: newRelease("v0.36.0", "deno_linux_x64.gz"), expected: "deno_linux_x64.gz"},
+		{version: "v0.35.0", os: OsLinux, arch: Archx64, release: newRelease("v0.35.0", "deno-x86_64-unknown-linux-gnu.zip"), expected: "deno-x86_64-unknown-linux-gnu.zip"},
+		// the release has a binary which is not in the table yet
+		{version: "v1.40.0", os: OsLinux, arch: Archarm64, release: newRelease("v1.40.0", "deno-aarch64-unknown-linux-gnu.zip"), expected: "deno-aarch64-unknown-linux-gnu.zip"},
+		// the release does not have the binary
+		{version: "v1.4.2", os: OsLinux, arch: Archx64, release: newRelease("v1.4.2", "deno-x86_64-apple-darwin.zip"), err: ErrNotSupport},
+	}
+
+	for _, test := range tests {
+		name := fmt.Sprintf("%s %s/%s", test.version, test.os, test.arch)
+
+		actual, err := getAssetName(test.version, test.os, test.arch, test.release)
+
+		if test.err != nil {
+			if errors.Cause(err) != test.err {
+				t.Errorf("expect %v for %s, got `%s` and %v", test.err, name, actual, err)
+			}
+
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("expect `%s` for %s, got %v", test.expected, name, err)
+		} else if actual != test.expected {
+			t.Errorf("expect `%s` for %s, got `%s`", test.expected, name, actual)
+		}
+	}
+}
+
+func TestFallbackAssetName(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/tags/v0.36.0":
+			_, _ = w.Write([]byte(`{"tag_name": "v0.36.0", "assets": [{"name": "deno_linux_x64.gz"}, {"name": "deno_osx_x64.gz"}]}`))
+		case "/tags/v1.4.2":
+			_, _ = w.Write([]byte(`{"tag_name": "v1.4.2", "assets": [{"name": "deno-x86_64-unknown-linux-gnu.zip"}]}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+
+	defer server.Close()
+
+	home, clean := testutil.TempDir(t)
+	defer clean()
+
+	defer testutil.Setenv(t, map[string]string{
+		"DENOX_HOME":            home,
+		"DENOX_RELEASE_SOURCES": "github=" + server.URL,
+		"GITHUB_TOKEN":          "",
+		"DENOX_GITHUB_TOKEN":    "",
+	})()
+
+	notFoundErr := errors.Wrap(ErrAssetNotFound, "404")
+
+	tests := []struct {
+		version  string
+		asset    string
+		expected string
+	}{
+		// the asset list has the legacy name
+		{version: "v0.36.0", asset: "deno-x86_64-unknown-linux-gnu.zip", expected: "deno_linux_x64.gz"},
+		// the asset list has the same name, nothing else to try
+		{version: "v1.4.2", asset: "deno-x86_64-unknown-linux-gnu.zip"},
+		// the release is not found
+		{version: "v9.9.9", asset: "deno-x86_64-unknown-linux-gnu.zip"},
+	}
+
+	for _, test := range tests {
+		d := &Deno{Version: test.version, Os: OsLinux, Arch: Archx64}
+
+		actual, err := d.fallbackAssetName(test.asset, notFoundErr)
+
+		if test.expected == "" {
+			if err != notFoundErr {
+				t.Errorf("expect the not found error for %s, got `%s` and %v", test.version, actual, err)
+			}
+
+			continue
+		}
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if actual != test.expected {
+			t.Errorf("expect `%s` for %s, got `%s`", test.expected, test.version, actual)
+		}
+	}
+}
@@ -0,0 +1,121 @@
+package deno
+
+import (
+	"bytes"
+	"os"
+
+	"github.com/axetroy/denox/internal/checksum"
+	"github.com/axetroy/denox/internal/fs"
+	"github.com/axetroy/denox/internal/logger"
+	"github.com/pkg/errors"
+)
+
+const (
+	// the checksum file of the project, found by walking up from cwd.
+	// the entry is `<hash>  <version>/<asset>`, eg `<hash>  v1.4.2/deno-x86_64-unknown-linux-gnu.zip`
+	SumFilename = "denox.sum"
+)
+
+var (
+	ErrChecksumNotFound = errors.New("checksum not found")
+)
+
+// look up the checksum of the asset in the sums, the entry with version goes first
+func lookupSums(sums checksum.Sums, version string, asset string) (string, bool) {
+	for _, name := range []string{version + "/" + asset, asset} {
+		if sum, ok := sums[name]; ok {
+			return sum, true
+		}
+	}
+
+	return "", false
+}
+
+// find the expected checksum of the asset from:
+// 1. the project checksum file `denox.sum`
+// 2. the checksum manifest at environment variable `DENOX_CHECKSUMS`
+// 3. the checksum asset `<asset>.sha256sum` of the release
+func (d *Deno) lookupChecksum(asset string) (sum string, from string, err error) {
+	cwd, err := os.Getwd()
+
+	if err != nil {
+		return "", "", errors.Wrap(err, "get current working dir fail")
+	}
+
+	if file, err := fs.FindUp(cwd, SumFilename); err != nil {
+		return "", "", err
+	} else if file != "" {
+		sums, err := checksum.ParseFile(file)
+
+		if err != nil {
+			return "", "", err
+		}
+
+		if sum, ok := lookupSums(sums, d.Version, asset); ok {
+			return sum, file, nil
+		}
+	}
+
+	if file := os.Getenv("DENOX_CHECKSUMS"); file != "" {
+		sums, err := checksum.ParseFile(file)
+
+		if err != nil {
+			return "", "", err
+		}
+
+		if sum, ok := lookupSums(sums, d.Version, asset); ok {
+			return sum, file, nil
+		}
+	}
+
+	for _, mirror := range getMirrors() {
+		sumURL := mirror.URL(d.Version, d.Os, d.Arch, asset+".sha256sum")
+
+		b, _, err := httpGet(sumURL)
+
+		if err != nil {
+			logger.Debugf("fetch checksum `%s` fail: %s", sumURL, err)
+			continue
+		}
+
+		sums, err := checksum.Parse(bytes.NewReader(b))
+
+		if err != nil {
+			return "", "", err
+		}
+
+		for _, name := range []string{asset, ""} {
+			if sum, ok := sums[name]; ok {
+				return sum, sumURL, nil
+			}
+		}
+	}
+
+	return "", "", ErrChecksumNotFound
+}
+
+// require the checksum of every asset with environment variable `DENOX_REQUIRE_CHECKSUM`.
+// the old releases have no checksum published, so a missing checksum is only a warning by default
+func getRequireChecksum() bool {
+	return os.Getenv("DENOX_REQUIRE_CHECKSUM") != ""
+}
+
+// get the expected checksum of the asset.
+// the sum is empty if there is no checksum for the asset, unless the checksum is required, see getRequireChecksum
+func (d *Deno) expectedChecksum(asset string) (sum string, from string, err error) {
+	sum, from, err = d.lookupChecksum(asset)
+
+	if err == ErrChecksumNotFound {
+		if getRequireChecksum() {
+			return "", "", errors.Wrapf(err, "%s %s, add it to %s or unset DENOX_REQUIRE_CHECKSUM to skip verification", d.Version, asset, SumFilename)
+		}
+
+		logger.Warnf("no checksum found for %s %s, skip verification. add it to %s to verify it", d.Version, asset, SumFilename)
+
+		return "", "", nil
+	} else if err != nil {
+		return "", "", errors.Wrap(err, "look up checksum fail")
+	}
+
+	return sum, from, nil
+}
@@ -1,16 +1,13 @@
 package deno
 
 import (
-	"encoding/json"
-	"fmt"
-	"io/ioutil"
-	"net/http"
 	"os"
 	"path"
 	"runtime"
 
 	"github.com/axetroy/denox/internal/fs"
-	"github.com/axetroy/denox/internal/utils"
+	"github.com/axetroy/denox/internal/lock"
+	"github.com/axetroy/denox/internal/logger"
 	"github.com/pkg/errors"
 )
 
@@ -26,23 +23,42 @@ const (
 	OsWindows Os = "win"
 	OsOsx     Os = "osx"
 
-	Archx64 Arch = "x64"
+	Archx64   Arch = "x64"
+	Archarm64 Arch = "arm64"
 )
 
 type Deno struct {
-	Version  string
-	Os       Os
-	Arch     Arch
-	cacheDir string
-	DenoDir  string
+	Version string
+	Os      Os
+	Arch    Arch
+	// the dir owned by denox where the version installed, the executable is in `bin`
+	InstallDir string
+	// the module cache of Deno, it is passed to Deno as environment variable `DENO_DIR`.
+	// it is set by PrepareDenoDir
+	DenoDir string
+	// verify the hash of the installed executable, even if its size and modification time match the install manifest
+	Verify        bool
+	denoDirPolicy DenoDirPolicy
+	stagingDir    string
+	ephemeralDir  string
 }
 
-func New(version *string) (*Deno, error) {
-	if version == nil {
-		if v, err := getLatestDenoVersion(); err != nil {
+// New create a Deno with the version spec, use the latest version if spec is nil.
+// see ResolveVersion for the supported spec
+func New(spec *string) (*Deno, error) {
+	var version *string
+
+	if spec == nil {
+		if v, err := ResolveVersion("latest"); err != nil {
 			return nil, err
 		} else {
-			version = v
+			version = &v
+		}
+	} else {
+		if v, err := ResolveVersion(*spec); err != nil {
+			return nil, errors.Wrapf(err, "resolve version `%s` fail", *spec)
+		} else {
+			version = &v
 		}
 	}
 
@@ -58,96 +74,143 @@ func New(version *string) (*Deno, error) {
 		return nil, err
 	}
 
-	cacheDir, err := getDenoCacheDir()
+	dataDir, err := getDataDir()
 
 	if err != nil {
 		return nil, err
 	}
 
-	homeDir, err := os.UserHomeDir()
+	policy, err := getDenoDirPolicy()
 
 	if err != nil {
 		return nil, err
 	}
 
-	DenoDir := ""
+	// the install dir is created when the installation is complete, so that a failed installation leaves nothing
+	return &Deno{
+		Os:            *denoOs,
+		Arch:          *denoArch,
+		Version:       *version,
+		InstallDir:    path.Join(dataDir, "deno_"+*version),
+		denoDirPolicy: policy,
+	}, nil
+}
 
-	if s := os.Getenv("DENO_DIR"); s != "" {
-		DenoDir = s
-	} else {
-		DenoDir = path.Join(homeDir, ".denox", "deno_"+*version)
+// clear the staging dir of the installation and the temporary DENO_DIR
+func (d *Deno) Clean() error {
+	if err := d.cleanStaging(); err != nil {
+		return err
 	}
 
-	if err := fs.EnsureDir(path.Join(DenoDir, "bin")); err != nil {
-		return nil, err
+	if d.ephemeralDir == "" {
+		return nil
 	}
 
-	return &Deno{
-		Os:       *denoOs,
-		Arch:     *denoArch,
-		Version:  *version,
-		cacheDir: cacheDir,
-		DenoDir:  DenoDir,
-	}, nil
+	return os.RemoveAll(d.ephemeralDir)
 }
 
-// clear download cache dir
-func (d *Deno) Clean() error {
-	return os.RemoveAll(d.cacheDir)
+// clear the staging dir of the installation
+func (d *Deno) cleanStaging() error {
+	if d.stagingDir == "" {
+		return nil
+	}
+
+	return os.RemoveAll(d.stagingDir)
 }
 
 // download Deno from remote and returns the path of the executable file
 func (d *Deno) Download() (executablePath string, err error) {
 	var (
-		tarExtName        = ".gz"
-		remoteTarFilename string
-		localTarFilename  string
-		dstDir            = path.Join(d.DenoDir, "bin")
+		dstDir = path.Join(d.InstallDir, "bin")
 	)
 
-	executablePath = path.Join(dstDir, "deno")
+	executablePath = path.Join(dstDir, ExecutableName(d.Os))
 
-	if d.Os == OsWindows {
-		tarExtName = ".zip"
-		executablePath += ".exe"
+	// if the installation is complete, no need to lock
+	if err := checkInstall(dstDir, ExecutableName(d.Os), d.Verify); err == nil {
+		return executablePath, nil
 	}
 
-	remoteTarFilename = fmt.Sprintf("deno_%s_%s%s", d.Os, d.Arch, tarExtName)
-	localTarFilename = fmt.Sprintf("deno_%s_%s_%s%s", d.Version, d.Os, d.Arch, tarExtName)
+	// only one process installs the version at the same time
+	lockFile, err := getLockFile("deno_" + d.Version)
 
-	localGzFilepath := path.Join(d.cacheDir, localTarFilename)
+	if err != nil {
+		return "", err
+	}
 
-	// download
-	downloadURL := fmt.Sprintf("https://github.com/denoland/deno/releases/download/%s/%s", d.Version, remoteTarFilename)
+	l, err := lock.Acquire(lockFile)
 
-	// if Deno executable file not exist
-	// then we should download it
-	if exit, err := fs.PathExists(executablePath); err != nil {
-		return "", errors.Wrapf(err, "stat file `%s` fail", executablePath)
-	} else if !exit {
-		// download the file for current platform
-		if _, err = utils.DownloadFile(localGzFilepath, downloadURL); err != nil {
-			return "", errors.Wrap(err, "download file fail")
-		} else {
+	if err != nil {
+		return "", err
+	}
 
+	defer func() {
+		if releaseErr := l.Release(); releaseErr != nil && err == nil {
+			err = releaseErr
 		}
+	}()
 
-		if _, err := utils.Decompress(localGzFilepath, dstDir); err != nil {
-			return "", errors.Wrapf(err, "decompress gzip file fail")
-		} else {
-			// make sure is it is a executable file
-			if d.Os != OsWindows {
-				mod := os.FileMode(0755)
-				if err := os.Chmod(executablePath, mod); err != nil {
-					err = errors.Wrap(err, "set permission fail")
-				}
+	// another process may have installed it while we were waiting for the lock
+	checkErr := checkInstall(dstDir, ExecutableName(d.Os), d.Verify)
+
+	if checkErr == nil {
+		return executablePath, nil
+	}
+
+	switch errors.Cause(checkErr) {
+	case ErrNoManifest:
+		// the installation without manifest, it may be installed by the old version of denox or migrated from `~/.denox`
+		if exist, err := fs.PathExists(executablePath); err != nil {
+			return "", errors.Wrapf(err, "stat file `%s` fail", executablePath)
+		} else if exist {
+			err := d.adopt(dstDir)
+
+			if err == nil {
+				return executablePath, nil
 			}
+
+			logger.Warnf("%s, reinstall Deno %s", err, d.Version)
 		}
+	case ErrCorruptInstall:
+		logger.Warnf("%s, reinstall Deno %s", checkErr, d.Version)
+	default:
+		return "", errors.Wrap(checkErr, "check installation fail")
+	}
+
+	if err := d.install(dstDir); err != nil {
+		return "", err
 	}
 
 	return executablePath, nil
 }
 
+// adopt the installation without manifest if it passes the smoke test, so that it is not reinstalled on every run
+func (d *Deno) adopt(binDir string) error {
+	executablePath := path.Join(binDir, ExecutableName(d.Os))
+
+	if err := d.smokeTest(executablePath); err != nil {
+		return err
+	}
+
+	logger.Debugf("adopt the installation without manifest `%s`", executablePath)
+
+	// the asset is unknown. it still works without the manifest, but it is checked again on the next run
+	if err := writeManifest(binDir, ExecutableName(d.Os), d.Version, ""); err != nil {
+		logger.Warnf("write install manifest of `%s` fail: %s", executablePath, err)
+	}
+
+	return nil
+}
+
+// ExecutableName returns the executable name of Deno on the OS
+func ExecutableName(denoOS Os) string {
+	if denoOS == OsWindows {
+		return "deno.exe"
+	}
+
+	return "deno"
+}
+
 // get deno OS
 func getDenoOS() (*Os, error) {
 	var denoOS Os
@@ -177,61 +240,12 @@ func getDenoArch() (*Arch, error) {
 	case "amd64":
 		denoArch = Archx64
 		break
+	case "arm64":
+		denoArch = Archarm64
+		break
 	default:
 		return nil, ErrNotSupport
 	}
 
 	return &denoArch, nil
 }
-
-// get latest version of Deno from remote
-func getLatestDenoVersion() (*string, error) {
-	r, err := http.Get("https://denolib.github.io/setup-deno/release.json")
-
-	if err != nil {
-		return nil, err
-	}
-
-	if r.StatusCode >= http.StatusBadRequest {
-		return nil, errors.New(r.Status)
-	}
-
-	b, err := ioutil.ReadAll(r.Body)
-
-	if err != nil {
-		return nil, errors.Wrap(err, "read body fail")
-	}
-
-	type Response struct {
-		Name string `json:"name"`
-	}
-
-	var res []Response
-
-	if err := json.Unmarshal(b, &res); err != nil {
-		return nil, errors.Wrap(err, "parse JSON fail")
-	}
-
-	if res == nil || len(res) == 0 {
-		return nil, errors.New("can not found version")
-	}
-
-	latestVersion := res[0].Name
-
-	return &latestVersion, nil
-}
-
-// get cache dir for deno
-func getDenoCacheDir() (string, error) {
-	if userCacheDir, err := os.UserCacheDir(); err != nil {
-		return "", errors.Wrap(err, "get user cache dir fail")
-	} else {
-		denoXCacheDir := path.Join(userCacheDir, "denox")
-
-		if err = fs.EnsureDir(denoXCacheDir); err != nil {
-			return "", errors.Wrap(err, "ensure denox cache dir fail")
-		}
-
-		return denoXCacheDir, nil
-	}
-}
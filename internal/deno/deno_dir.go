@@ -0,0 +1,236 @@
+package deno
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/axetroy/denox/internal/config"
+	"github.com/axetroy/denox/internal/fs"
+	"github.com/axetroy/denox/internal/lock"
+	"github.com/axetroy/denox/internal/logger"
+	"github.com/pkg/errors"
+)
+
+// DenoDirPolicy decides how the versions of Deno share the `DENO_DIR`
+type DenoDirPolicy string
+
+const (
+	// each version has its own `DENO_DIR`
+	DenoDirPerVersion DenoDirPolicy = "per-version"
+	// the remote modules are shared by all versions, the compiled cache `gen` is still per version
+	DenoDirShared DenoDirPolicy = "shared"
+	// a temporary `DENO_DIR` which is removed after the run
+	DenoDirEphemeral DenoDirPolicy = "ephemeral"
+)
+
+var (
+	ErrUnknownDenoDirPolicy = errors.New("unknown DENO_DIR policy")
+)
+
+// the dirs of `DENO_DIR` linked to the shared dir with policy `shared`.
+// they are independent of the version of Deno, unlike `gen`
+var sharedDenoDirs = []string{"deps", "npm"}
+
+// get the policy from config `deno_dir_policy`
+func getDenoDirPolicy() (DenoDirPolicy, error) {
+	v, ok := config.Default().Lookup("deno_dir_policy")
+
+	if !ok {
+		return DenoDirPerVersion, nil
+	}
+
+	switch policy := DenoDirPolicy(v.Value); policy {
+	case DenoDirPerVersion, DenoDirShared, DenoDirEphemeral:
+		logger.Debugf("use DENO_DIR policy `%s` from %s", policy, v.Origin())
+		return policy, nil
+	default:
+		return "", errors.Wrapf(ErrUnknownDenoDirPolicy, "`%s` from %s, expect one of `%s`, `%s` and `%s`",
+			v.Value, v.Origin(), DenoDirPerVersion, DenoDirShared, DenoDirEphemeral)
+	}
+}
+
+// PrepareDenoDir set DenoDir with the policy from config `deno_dir_policy`, unless environment variable `DENO_DIR` is set.
+// the version must have been installed, because the `DENO_DIR` of policy `per-version` and `shared` is in the install dir
+func (d *Deno) PrepareDenoDir() (err error) {
+	// DENO_DIR only changes the module cache, never the installation
+	if s := os.Getenv("DENO_DIR"); s != "" {
+		logger.Debugf("environment variable DENO_DIR is set, ignore DENO_DIR policy `%s`", d.denoDirPolicy)
+
+		d.DenoDir = s
+
+		// the old version of denox installed Deno into DENO_DIR
+		if exist, _ := fs.PathExists(filepath.Join(d.DenoDir, "bin", manifestFilename)); exist {
+			logger.Debugf("`%s` is installed by the old version of denox, it is not used any more and can be removed", filepath.Join(d.DenoDir, "bin"))
+		}
+	} else if d.DenoDir, err = d.prepareDenoDir(d.denoDirPolicy); err != nil {
+		return err
+	}
+
+	return fs.EnsureDir(d.DenoDir)
+}
+
+// prepare the `DENO_DIR` of the policy and returns it
+func (d *Deno) prepareDenoDir(policy DenoDirPolicy) (denoDir string, err error) {
+	if policy == DenoDirEphemeral {
+		dir, err := ioutil.TempDir("", "denox-deno-dir-")
+
+		if err != nil {
+			return "", errors.Wrap(err, "create temporary DENO_DIR fail")
+		}
+
+		logger.Debugf("use temporary DENO_DIR `%s`", dir)
+
+		d.ephemeralDir = dir
+
+		return dir, nil
+	}
+
+	dataDir, err := getDataDir()
+
+	if err != nil {
+		return "", err
+	}
+
+	// the links are created only once, so check them without the lock first
+	if sharedDirsReady(policy, d.InstallDir, dataDir) {
+		return d.InstallDir, nil
+	}
+
+	lockFile, err := getLockFile("deno_" + d.Version)
+
+	if err != nil {
+		return "", err
+	}
+
+	l, err := lock.Acquire(lockFile)
+
+	if err != nil {
+		return "", err
+	}
+
+	defer func() {
+		if releaseErr := l.Release(); releaseErr != nil && err == nil {
+			err = releaseErr
+		}
+	}()
+
+	for _, name := range sharedDenoDirs {
+		link := filepath.Join(d.InstallDir, name)
+
+		if policy != DenoDirShared {
+			if err := unlinkSharedDir(link); err != nil {
+				return "", err
+			}
+
+			continue
+		}
+
+		// eg creating symlinks requires the developer mode on Windows
+		if err := linkSharedDir(link, filepath.Join(dataDir, "shared", name)); err != nil {
+			logger.Warnf("%s, `%s` is not shared", err, name)
+		}
+	}
+
+	return d.InstallDir, nil
+}
+
+// whether the links to the shared dirs are already as the policy wants
+func sharedDirsReady(policy DenoDirPolicy, denoDir string, dataDir string) bool {
+	for _, name := range sharedDenoDirs {
+		link := filepath.Join(denoDir, name)
+		info, err := os.Lstat(link)
+		isLink := err == nil && info.Mode()&os.ModeSymlink != 0
+
+		if policy != DenoDirShared {
+			if isLink {
+				return false
+			}
+
+			continue
+		}
+
+		shared := filepath.Join(dataDir, "shared", name)
+
+		if !isLink {
+			return false
+		}
+
+		if target, err := os.Readlink(link); err != nil || target != shared {
+			return false
+		}
+
+		if exist, err := fs.PathExists(shared); err != nil || !exist {
+			return false
+		}
+	}
+
+	return true
+}
+
+// make link a symlink to the shared dir.
+// the existing dir is moved to the shared dir if it does not exist yet, otherwise it is removed
+func linkSharedDir(link string, shared string) error {
+	info, err := os.Lstat(link)
+
+	switch {
+	case os.IsNotExist(err):
+	case err != nil:
+		return errors.Wrapf(err, "stat file `%s` fail", link)
+	case info.Mode()&os.ModeSymlink != 0:
+		if target, err := os.Readlink(link); err == nil && target == shared {
+			return nil
+		}
+
+		if err := os.Remove(link); err != nil {
+			return errors.Wrapf(err, "remove link `%s` fail", link)
+		}
+	case info.IsDir():
+		if exist, err := fs.PathExists(shared); err != nil {
+			return errors.Wrapf(err, "stat file `%s` fail", shared)
+		} else if !exist {
+			if err := fs.EnsureDir(filepath.Dir(shared)); err != nil {
+				return errors.Wrapf(err, "ensure dir `%s` fail", filepath.Dir(shared))
+			}
+
+			logger.Debugf("move `%s` to the shared dir `%s`", link, shared)
+
+			if err := os.Rename(link, shared); err != nil {
+				return errors.Wrapf(err, "move `%s` to `%s` fail", link, shared)
+			}
+		} else {
+			logger.Debugf("remove `%s` in favor of the shared dir `%s`", link, shared)
+
+			if err := os.RemoveAll(link); err != nil {
+				return errors.Wrapf(err, "remove dir `%s` fail", link)
+			}
+		}
+	default:
+		return errors.Errorf("`%s` is not a dir", link)
+	}
+
+	if err := fs.EnsureDir(shared); err != nil {
+		return errors.Wrapf(err, "ensure dir `%s` fail", shared)
+	}
+
+	if err := os.Symlink(shared, link); err != nil {
+		return errors.Wrapf(err, "link `%s` to `%s` fail", link, shared)
+	}
+
+	return nil
+}
+
+// remove the symlink to the shared dir, the shared dir is untouched
+func unlinkSharedDir(link string) error {
+	if info, err := os.Lstat(link); err != nil || info.Mode()&os.ModeSymlink == 0 {
+		return nil
+	}
+
+	logger.Debugf("unlink `%s` from the shared dir", link)
+
+	if err := os.Remove(link); err != nil {
+		return errors.Wrapf(err, "remove link `%s` fail", link)
+	}
+
+	return nil
+}
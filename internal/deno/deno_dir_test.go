@@ -0,0 +1,174 @@
+package deno
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/axetroy/denox/internal/testutil"
+)
+
+func TestLinkSharedDir(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("creating symlinks requires the developer mode on Windows")
+	}
+
+	tests := []struct {
+		name string
+		// prepare the link and the shared dir in the dir
+		prepare func(link string, shared string) error
+		err     bool
+		// the file in the shared dir after linking
+		file string
+	}{
+		{
+			name:    "not exist",
+			prepare: func(link string, shared string) error { return nil },
+		},
+		{
+			name: "move the dir to the shared dir",
+			prepare: func(link string, shared string) error {
+				if err := os.MkdirAll(link, 0755); err != nil {
+					return err
+				}
+
+				return ioutil.WriteFile(filepath.Join(link, "mod.ts"), nil, 0644)
+			},
+			file: "mod.ts",
+		},
+		{
+			name: "remove the dir in favor of the shared dir",
+			prepare: func(link string, shared string) error {
+				if err := os.MkdirAll(link, 0755); err != nil {
+					return err
+				}
+
+				if err := ioutil.WriteFile(filepath.Join(link, "old.ts"), nil, 0644); err != nil {
+					return err
+				}
+
+				if err := os.MkdirAll(shared, 0755); err != nil {
+					return err
+				}
+
+				return ioutil.WriteFile(filepath.Join(shared, "mod.ts"), nil, 0644)
+			},
+			file: "mod.ts",
+		},
+		{
+			name: "replace the link to the other dir",
+			prepare: func(link string, shared string) error {
+				return os.Symlink(filepath.Dir(shared), link)
+			},
+		},
+		{
+			name: "already linked",
+			prepare: func(link string, shared string) error {
+				if err := os.MkdirAll(shared, 0755); err != nil {
+					return err
+				}
+
+				return os.Symlink(shared, link)
+			},
+		},
+		{
+			name: "not a dir",
+			prepare: func(link string, shared string) error {
+				return ioutil.WriteFile(link, nil, 0644)
+			},
+			err: true,
+		},
+	}
+
+	for _, test := range tests {
+		dir, clean := testutil.TempDir(t)
+
+		link := filepath.Join(dir, "deno_v1.4.2", "deps")
+		shared := filepath.Join(dir, "shared", "deps")
+
+		err := os.MkdirAll(filepath.Dir(link), 0755)
+
+		if err == nil {
+			err = test.prepare(link, shared)
+		}
+
+		if err != nil {
+			clean()
+			t.Fatal(err)
+		}
+
+		err = linkSharedDir(link, shared)
+
+		if test.err {
+			if err == nil {
+				t.Errorf("%s: expect error, got nil", test.name)
+			}
+
+			clean()
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("%s: %v", test.name, err)
+		} else if target, err := os.Readlink(link); err != nil || target != shared {
+			t.Errorf("%s: expect `%s` to link to `%s`, got `%s` %v", test.name, link, shared, target, err)
+		} else if test.file != "" {
+			if _, err := os.Stat(filepath.Join(link, test.file)); err != nil {
+				t.Errorf("%s: expect `%s` in the shared dir, got %v", test.name, test.file, err)
+			}
+		}
+
+		clean()
+	}
+}
+
+func TestSharedDirsReady(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("creating symlinks requires the developer mode on Windows")
+	}
+
+	dataDir, clean := testutil.TempDir(t)
+	defer clean()
+
+	denoDir := filepath.Join(dataDir, "deno_v1.4.2")
+
+	if err := os.MkdirAll(denoDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	check := func(step string, policy DenoDirPolicy, expect bool) {
+		if actual := sharedDirsReady(policy, denoDir, dataDir); actual != expect {
+			t.Errorf("%s: expect sharedDirsReady(%s) to be %v, got %v", step, policy, expect, actual)
+		}
+	}
+
+	check("nothing linked", DenoDirPerVersion, true)
+	check("nothing linked", DenoDirShared, false)
+
+	for _, name := range sharedDenoDirs {
+		if err := linkSharedDir(filepath.Join(denoDir, name), filepath.Join(dataDir, "shared", name)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	check("linked", DenoDirShared, true)
+	check("linked", DenoDirPerVersion, false)
+
+	// the shared dir is removed by hand
+	if err := os.RemoveAll(filepath.Join(dataDir, "shared", sharedDenoDirs[0])); err != nil {
+		t.Fatal(err)
+	}
+
+	check("shared dir removed", DenoDirShared, false)
+
+	for _, name := range sharedDenoDirs {
+		if err := unlinkSharedDir(filepath.Join(denoDir, name)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	check("unlinked", DenoDirPerVersion, true)
+	check("unlinked", DenoDirShared, false)
+}
@@ -0,0 +1,36 @@
+package deno
+
+import (
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/axetroy/denox/internal/download"
+	"github.com/axetroy/denox/internal/httpclient"
+)
+
+// get the downloader with the options from environment variables:
+// `DENOX_DOWNLOAD_RETRIES`, `DENOX_DOWNLOAD_CONNECTIONS` and `DENOX_IDLE_TIMEOUT`
+func getDownloader() (*download.Downloader, error) {
+	client, err := httpclient.Default()
+
+	if err != nil {
+		return nil, err
+	}
+
+	d := download.New(client)
+
+	if n, err := strconv.Atoi(os.Getenv("DENOX_DOWNLOAD_RETRIES")); err == nil && n >= 0 {
+		d.Retries = n
+	}
+
+	if n, err := strconv.Atoi(os.Getenv("DENOX_DOWNLOAD_CONNECTIONS")); err == nil && n > 0 {
+		d.Connections = n
+	}
+
+	if timeout, err := time.ParseDuration(os.Getenv("DENOX_IDLE_TIMEOUT")); err == nil {
+		d.IdleTimeout = timeout
+	}
+
+	return d, nil
+}
@@ -0,0 +1,179 @@
+package deno
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/axetroy/denox/internal/archive"
+	"github.com/axetroy/denox/internal/checksum"
+	"github.com/axetroy/denox/internal/fs"
+	"github.com/axetroy/denox/internal/logger"
+	"github.com/axetroy/denox/internal/progress"
+	"github.com/pkg/errors"
+)
+
+// extract the archive while downloading or not, enable it with environment variable `DENOX_STREAM_EXTRACT`
+func getStreamExtract() bool {
+	return os.Getenv("DENOX_STREAM_EXTRACT") != ""
+}
+
+// download the asset and extract it into dir, returns the extracted files.
+// the checksum is verified before return, it is looked up after the download starts,
+// so that ErrAssetNotFound is returned for the asset which does not exist rather than ErrChecksumNotFound
+func (d *Deno) downloadAndExtract(asset string, dir string) ([]string, error) {
+	if getStreamExtract() {
+		files, err := d.streamExtract(asset, dir)
+
+		if err == nil {
+			return files, nil
+		}
+
+		switch errors.Cause(err) {
+		case checksum.ErrMismatch, ErrChecksumNotFound, ErrAssetNotFound:
+			return nil, err
+		}
+
+		logger.Warnf("extract while downloading fail, download the archive instead: %s", err)
+
+		if err := os.RemoveAll(dir); err != nil {
+			return nil, errors.Wrapf(err, "remove dir `%s` fail", dir)
+		}
+	}
+
+	cacheDir, err := getDenoCacheDir()
+
+	if err != nil {
+		return nil, err
+	}
+
+	downloadDir := path.Join(cacheDir, "download")
+
+	if err := fs.EnsureDir(downloadDir); err != nil {
+		return nil, errors.Wrap(err, "ensure download dir fail")
+	}
+
+	// the archive is downloaded to the cache dir, so that the partial download can be resumed next time.
+	// it is safe because we hold the install lock of the version
+	archiveFilepath := path.Join(downloadDir, d.Version+"_"+asset)
+
+	defer os.Remove(archiveFilepath)
+
+	// download the file for current platform
+	if err := d.downloadFromMirrors(archiveFilepath, asset); err != nil {
+		return nil, errors.Wrap(err, "download file fail")
+	}
+
+	sum, from, err := d.expectedChecksum(asset)
+
+	if err != nil {
+		return nil, errors.Wrap(err, "verify checksum fail")
+	}
+
+	if sum != "" {
+		if err := checksum.Verify(archiveFilepath, sum); err != nil {
+			_ = os.Remove(archiveFilepath)
+			return nil, errors.Wrap(err, "verify checksum fail")
+		}
+
+		logger.Debugf("checksum of `%s` matches %s", archiveFilepath, from)
+	}
+
+	progress.Default().Event("extract", progress.Fields{"file": archiveFilepath, "dir": dir})
+
+	files, err := archive.Extract(archiveFilepath, dir, archive.DefaultOptions())
+
+	if err != nil {
+		return nil, errors.Wrap(err, "decompress file fail")
+	}
+
+	return files, nil
+}
+
+// pipe the response through the hashing reader into the decompressor, the archive never touches the disk
+func (d *Deno) streamExtract(asset string, dir string) ([]string, error) {
+	downloader, err := getDownloader()
+
+	if err != nil {
+		return nil, err
+	}
+
+	messages := make([]string, 0)
+	notFound := true
+
+	for _, mirror := range getMirrors() {
+		downloadURL := mirror.URL(d.Version, d.Os, d.Arch, asset)
+
+		logger.Debugf("download and extract `%s`", downloadURL)
+
+		// the files of the failed mirror
+		if err := os.RemoveAll(dir); err != nil {
+			return nil, errors.Wrapf(err, "remove dir `%s` fail", dir)
+		}
+
+		var (
+			files []string
+			// the error of checksum, it is not worth trying other mirrors
+			checksumErr error
+		)
+
+		err := downloader.Stream(downloadURL, asset, func(body io.Reader) error {
+			progress.Default().Event("extract", progress.Fields{"url": downloadURL, "dir": dir})
+
+			r := checksum.NewReader(body)
+
+			var err error
+
+			if files, err = archive.ExtractReader(r, asset, dir, archive.DefaultOptions()); err != nil {
+				return errors.Wrap(err, "decompress file fail")
+			}
+
+			// the rest of the archive, eg the central directory of zip, is covered by the checksum too
+			if _, err := io.Copy(ioutil.Discard, r); err != nil {
+				return errors.Wrap(err, "read body fail")
+			}
+
+			sum, from, err := d.expectedChecksum(asset)
+
+			if err != nil {
+				checksumErr = errors.Wrap(err, "verify checksum fail")
+				return checksumErr
+			}
+
+			if sum == "" {
+				return nil
+			}
+
+			if err := r.Verify(downloadURL, sum); err != nil {
+				checksumErr = err
+				return checksumErr
+			}
+
+			logger.Debugf("checksum of `%s` matches %s", downloadURL, from)
+
+			return nil
+		})
+
+		if checksumErr != nil {
+			return nil, checksumErr
+		}
+
+		if err != nil {
+			logger.Debugf("download and extract `%s` fail: %s", downloadURL, err)
+			messages = append(messages, fmt.Sprintf("%s: %s", downloadURL, err))
+			notFound = notFound && isNotFound(err)
+			continue
+		}
+
+		return files, nil
+	}
+
+	if notFound {
+		return nil, errors.Wrap(ErrAssetNotFound, strings.Join(messages, "; "))
+	}
+
+	return nil, errors.New(strings.Join(messages, "; "))
+}
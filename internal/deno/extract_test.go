@@ -0,0 +1,117 @@
+package deno
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/axetroy/denox/internal/checksum"
+	"github.com/axetroy/denox/internal/testutil"
+	"github.com/pkg/errors"
+)
+
+func TestStreamExtractChecksumMismatch(t *testing.T) {
+	const asset = "deno-x86_64-unknown-linux-gnu.zip"
+
+	buf := &bytes.Buffer{}
+	w := zip.NewWriter(buf)
+
+	f, err := w.Create("deno")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := f.Write([]byte("#!/bin/sh\necho 'deno 1.4.2'\n")); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	archive := buf.Bytes()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1.4.2/"+asset {
+			http.NotFound(w, r)
+			return
+		}
+
+		_, _ = w.Write(archive)
+	}))
+
+	defer server.Close()
+
+	home, clean := testutil.TempDir(t)
+	defer clean()
+
+	sumFile := filepath.Join(home, "SHA256SUMS")
+
+	defer testutil.Setenv(t, map[string]string{
+		"DENOX_HOME":                 home,
+		"DENOX_MIRROR":               server.URL,
+		"DENOX_STREAM_EXTRACT":       "1",
+		"DENOX_CHECKSUMS":            sumFile,
+		"DENOX_DOWNLOAD_RETRIES":     "0",
+		"DENOX_DOWNLOAD_CONNECTIONS": "1",
+		"DENOX_PROGRESS":             "none",
+		"DENOX_SKIP_LIBC_CHECK":      "1",
+		"DENOX_SKIP_SMOKE_TEST":      "1",
+	})()
+
+	hash := sha256.Sum256(archive)
+	wrongHash := sha256.Sum256([]byte("evil archive"))
+
+	tests := []struct {
+		name string
+		sum  string
+		err  error
+	}{
+		{name: "checksum mismatch", sum: hex.EncodeToString(wrongHash[:]), err: checksum.ErrMismatch},
+		{name: "checksum match", sum: hex.EncodeToString(hash[:])},
+	}
+
+	for _, test := range tests {
+		if err := ioutil.WriteFile(sumFile, []byte(test.sum+"  v1.4.2/"+asset+"\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		d := &Deno{Version: "v1.4.2", Os: OsLinux, Arch: Archx64, InstallDir: filepath.Join(home, "deno_v1.4.2")}
+
+		executablePath, err := d.Download()
+
+		// nothing is left in the data dir, eg the staging dir
+		if matches, _ := filepath.Glob(filepath.Join(home, stagingPrefix+"*")); len(matches) != 0 {
+			t.Errorf("%s: expect no staging dir left, got %v", test.name, matches)
+		}
+
+		if test.err != nil {
+			if errors.Cause(err) != test.err {
+				t.Errorf("%s: expect %v, got %v", test.name, test.err, err)
+			}
+
+			// the atomic rename never happens
+			if _, err := os.Stat(d.InstallDir); !os.IsNotExist(err) {
+				t.Errorf("%s: expect no install dir `%s`, got %v", test.name, d.InstallDir, err)
+			}
+
+			continue
+		}
+
+		if err != nil {
+			t.Fatalf("%s: %v", test.name, err)
+		}
+
+		if err := checkInstall(filepath.Dir(executablePath), ExecutableName(d.Os), true); err != nil {
+			t.Errorf("%s: expect a complete installation, got %v", test.name, err)
+		}
+	}
+}
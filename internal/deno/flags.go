@@ -0,0 +1,51 @@
+package deno
+
+import (
+	"strings"
+
+	"github.com/axetroy/denox/internal/config"
+	"github.com/axetroy/denox/internal/logger"
+)
+
+// the subcommands of Deno which run the code, so that they accept the flags like `--unstable` and `--allow-*`
+var runSubcommands = map[string]bool{
+	"run":     true,
+	"test":    true,
+	"bench":   true,
+	"eval":    true,
+	"repl":    true,
+	"compile": true,
+	"install": true,
+}
+
+// WithDefaultFlags insert the flags of config `deno_flags` after the subcommand of Deno.
+// the args are returned as it is if the subcommand does not run the code, eg `fmt` and `--version`
+func WithDefaultFlags(args []string) []string {
+	v, ok := config.Default().Lookup("deno_flags")
+
+	if !ok {
+		return args
+	}
+
+	flags := strings.Fields(v.Value)
+
+	// the REPL
+	if len(args) == 0 {
+		logger.Debugf("use default flags `%s` of Deno from %s", v.Value, v.Origin())
+		return flags
+	}
+
+	if !runSubcommands[args[0]] {
+		logger.Debugf("skip default flags `%s` of Deno for `%s`", v.Value, args[0])
+		return args
+	}
+
+	logger.Debugf("use default flags `%s` of Deno from %s", v.Value, v.Origin())
+
+	result := make([]string, 0, len(args)+len(flags))
+	result = append(result, args[0])
+	result = append(result, flags...)
+	result = append(result, args[1:]...)
+
+	return result
+}
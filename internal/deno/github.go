@@ -0,0 +1,371 @@
+package deno
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/axetroy/denox/internal/fs"
+	"github.com/axetroy/denox/internal/logger"
+	"github.com/pkg/errors"
+)
+
+const (
+	// stop paging the GitHub API at some point
+	maxGitHubPages = 20
+
+	// how long to wait for the reset of rate limit, can be overridden with environment variable `DENOX_GITHUB_MAX_WAIT`
+	DefaultGitHubMaxWait = time.Minute
+)
+
+var (
+	ErrRateLimited = errors.New("GitHub API rate limit exceeded")
+)
+
+var linkNextRegexp = regexp.MustCompile(`<([^>]+)>;\s*rel="next"`)
+
+// get the token of GitHub API from environment variable `DENOX_GITHUB_TOKEN` or `GITHUB_TOKEN`
+func getGitHubToken() string {
+	if s := os.Getenv("DENOX_GITHUB_TOKEN"); s != "" {
+		return s
+	}
+
+	return os.Getenv("GITHUB_TOKEN")
+}
+
+// get the max duration to wait for the reset of rate limit
+func getGitHubMaxWait() time.Duration {
+	if s := os.Getenv("DENOX_GITHUB_MAX_WAIT"); s != "" {
+		if d, err := time.ParseDuration(s); err == nil {
+			return d
+		}
+	}
+
+	return DefaultGitHubMaxWait
+}
+
+// the GitHub Releases API
+type githubSource struct {
+	url string
+}
+
+func (s *githubSource) Name() string {
+	return s.url
+}
+
+func (s *githubSource) Releases() ([]Release, error) {
+	releases := make([]Release, 0)
+
+	next := s.url + "?per_page=100"
+
+	for page := 0; next != "" && page < maxGitHubPages; page++ {
+		b, link, err := s.get(next)
+
+		if err != nil {
+			return nil, err
+		}
+
+		items, err := parseReleaseJSON(b)
+
+		if err != nil {
+			return nil, err
+		}
+
+		releases = append(releases, items...)
+
+		next = ""
+
+		if matches := linkNextRegexp.FindStringSubmatch(link); len(matches) == 2 {
+			next = matches[1]
+		}
+	}
+
+	return releases, nil
+}
+
+// Release fetch the release of the tag, with its asset list
+func (s *githubSource) Release(tag string) (*Release, error) {
+	b, _, err := s.get(s.url + "/tags/" + url.PathEscape(tag))
+
+	if err != nil {
+		return nil, err
+	}
+
+	var item releaseJSON
+
+	if err := json.Unmarshal(b, &item); err != nil {
+		return nil, errors.Wrap(err, "parse JSON fail")
+	}
+
+	release, ok := item.release()
+
+	if !ok {
+		return nil, errors.Wrapf(ErrVersionNotFound, "%s", tag)
+	}
+
+	return &release, nil
+}
+
+// fetch the release of the tag from the GitHub sources in `DENOX_RELEASE_SOURCES`.
+// the other sources are not asked, because they may have no asset list
+func fetchGitHubRelease(tag string) (*Release, error) {
+	sources, err := getReleaseSources()
+
+	if err != nil {
+		return nil, err
+	}
+
+	messages := make([]string, 0)
+
+	for _, source := range sources {
+		githubSource, ok := source.(*githubSource)
+
+		if !ok {
+			continue
+		}
+
+		release, err := githubSource.Release(tag)
+
+		if err != nil {
+			logger.Debugf("fetch release %s from %s fail: %s", tag, source.Name(), err)
+			messages = append(messages, fmt.Sprintf("%s: %s", source.Name(), err))
+			continue
+		}
+
+		return release, nil
+	}
+
+	if len(messages) == 0 {
+		return nil, errors.New("no GitHub release source")
+	}
+
+	return nil, errors.New(strings.Join(messages, "; "))
+}
+
+// get a page of the API, returns the body and the `Link` header.
+// the response is cached on disk and revalidated with `If-None-Match`,
+// which does not count against the rate limit
+func (s *githubSource) get(u string) ([]byte, string, error) {
+	cached := loadGitHubResponse(u)
+
+	for waited := false; ; waited = true {
+		req, err := http.NewRequest(http.MethodGet, u, nil)
+
+		if err != nil {
+			return nil, "", err
+		}
+
+		req.Header.Set("Accept", "application/vnd.github.v3+json")
+
+		// never send the token to other hosts
+		if token := getGitHubToken(); token != "" && sameHost(u, s.url) {
+			req.Header.Set("Authorization", "token "+token)
+		}
+
+		if cached != nil {
+			req.Header.Set("If-None-Match", cached.ETag)
+		}
+
+		r, b, err := httpDo(req)
+
+		if err != nil {
+			return nil, "", err
+		}
+
+		logger.Debugf("GET %s: %s, rate limit remaining %s", u, r.Status, r.Header.Get("X-RateLimit-Remaining"))
+
+		if r.StatusCode == http.StatusNotModified && cached != nil {
+			return cached.Body, cached.Link, nil
+		}
+
+		if r.StatusCode < http.StatusBadRequest {
+			if etag := r.Header.Get("ETag"); etag != "" {
+				saveGitHubResponse(&githubResponse{URL: u, ETag: etag, Link: r.Header.Get("Link"), Body: b})
+			}
+
+			return b, r.Header.Get("Link"), nil
+		}
+
+		wait, limited := getRateLimitWait(r)
+
+		if !limited {
+			return nil, "", errors.Errorf("%s: %s", r.Status, getGitHubMessage(b))
+		}
+
+		if !waited && wait <= getGitHubMaxWait() {
+			logger.Warnf("%s, wait %s for the reset", ErrRateLimited, wait.Round(time.Second))
+			time.Sleep(wait)
+			continue
+		}
+
+		err = &rateLimitError{wait: wait, authenticated: getGitHubToken() != ""}
+
+		if cached != nil {
+			logger.Warnf("%s, use the cached response of %s", err, u)
+			return cached.Body, cached.Link, nil
+		}
+
+		return nil, "", err
+	}
+}
+
+type rateLimitError struct {
+	wait          time.Duration
+	authenticated bool
+}
+
+func (e *rateLimitError) Error() string {
+	msg := fmt.Sprintf("%s, reset in %s", ErrRateLimited, e.wait.Round(time.Second))
+
+	if !e.authenticated {
+		msg += ", set environment variable `GITHUB_TOKEN` or `DENOX_GITHUB_TOKEN` to raise the limit"
+	}
+
+	return msg
+}
+
+func (e *rateLimitError) Cause() error {
+	return ErrRateLimited
+}
+
+// get how long to wait if the response is rejected by the rate limit
+func getRateLimitWait(r *http.Response) (time.Duration, bool) {
+	if r.StatusCode != http.StatusForbidden && r.StatusCode != http.StatusTooManyRequests {
+		return 0, false
+	}
+
+	// the secondary rate limit
+	if s := r.Header.Get("Retry-After"); s != "" {
+		if seconds, err := strconv.Atoi(s); err == nil {
+			return time.Duration(seconds) * time.Second, true
+		}
+	}
+
+	if r.Header.Get("X-RateLimit-Remaining") != "0" {
+		return 0, r.StatusCode == http.StatusTooManyRequests
+	}
+
+	reset, err := strconv.ParseInt(r.Header.Get("X-RateLimit-Reset"), 10, 64)
+
+	if err != nil {
+		return time.Minute, true
+	}
+
+	wait := time.Until(time.Unix(reset, 0)) + time.Second
+
+	if wait < 0 {
+		wait = 0
+	}
+
+	return wait, true
+}
+
+// get the message from the error response of GitHub API
+func getGitHubMessage(b []byte) string {
+	var body struct {
+		Message string `json:"message"`
+	}
+
+	if err := json.Unmarshal(b, &body); err != nil || body.Message == "" {
+		return string(b)
+	}
+
+	return body.Message
+}
+
+func sameHost(a, b string) bool {
+	ua, err := url.Parse(a)
+
+	if err != nil {
+		return false
+	}
+
+	ub, err := url.Parse(b)
+
+	if err != nil {
+		return false
+	}
+
+	return ua.Host == ub.Host
+}
+
+// the response of GitHub API cached on disk
+type githubResponse struct {
+	URL  string          `json:"url"`
+	ETag string          `json:"etag"`
+	Link string          `json:"link,omitempty"`
+	Body json.RawMessage `json:"body"`
+}
+
+func getGitHubResponseFilepath(u string) (string, error) {
+	cacheDir, err := getDenoCacheDir()
+
+	if err != nil {
+		return "", err
+	}
+
+	dir := path.Join(cacheDir, "github")
+
+	if err := fs.EnsureDir(dir); err != nil {
+		return "", err
+	}
+
+	hash := sha256.Sum256([]byte(u))
+
+	return path.Join(dir, hex.EncodeToString(hash[:])+".json"), nil
+}
+
+// load the cached response of the URL, returns nil if there is no cache
+func loadGitHubResponse(u string) *githubResponse {
+	file, err := getGitHubResponseFilepath(u)
+
+	if err != nil {
+		return nil
+	}
+
+	b, err := ioutil.ReadFile(file)
+
+	if err != nil {
+		return nil
+	}
+
+	var response githubResponse
+
+	// a broken cache is the same as no cache
+	if err := json.Unmarshal(b, &response); err != nil || response.URL != u || response.ETag == "" {
+		return nil
+	}
+
+	return &response
+}
+
+// save the response, the cache is optional so that the error is only logged
+func saveGitHubResponse(response *githubResponse) {
+	file, err := getGitHubResponseFilepath(response.URL)
+
+	if err != nil {
+		logger.Debugf("save GitHub response fail: %s", err)
+		return
+	}
+
+	b, err := json.Marshal(response)
+
+	if err != nil {
+		logger.Debugf("save GitHub response fail: %s", err)
+		return
+	}
+
+	if err := fs.WriteFileAtomic(file, b); err != nil {
+		logger.Debugf("save GitHub response fail: %s", err)
+	}
+}
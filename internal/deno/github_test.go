@@ -0,0 +1,190 @@
+package deno
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/axetroy/denox/internal/testutil"
+	"github.com/pkg/errors"
+)
+
+func TestGetRateLimitWait(t *testing.T) {
+	reset := strconv.FormatInt(time.Now().Add(time.Hour).Unix(), 10)
+	past := strconv.FormatInt(time.Now().Add(-time.Hour).Unix(), 10)
+
+	tests := []struct {
+		status  int
+		header  map[string]string
+		limited bool
+		min     time.Duration
+		max     time.Duration
+	}{
+		{status: http.StatusOK, header: map[string]string{"X-RateLimit-Remaining": "0"}},
+		{status: http.StatusNotFound, header: map[string]string{"Retry-After": "10"}},
+		// forbidden for other reasons
+		{status: http.StatusForbidden, header: map[string]string{"X-RateLimit-Remaining": "42"}},
+		{status: http.StatusForbidden},
+		// the secondary rate limit
+		{status: http.StatusForbidden, header: map[string]string{"Retry-After": "10"}, limited: true, min: 10 * time.Second, max: 10 * time.Second},
+		{status: http.StatusTooManyRequests, header: map[string]string{"Retry-After": "3"}, limited: true, min: 3 * time.Second, max: 3 * time.Second},
+		{status: http.StatusTooManyRequests, limited: true},
+		// the primary rate limit
+		{status: http.StatusForbidden, header: map[string]string{"X-RateLimit-Remaining": "0", "X-RateLimit-Reset": reset}, limited: true, min: 59 * time.Minute, max: time.Hour + time.Second},
+		{status: http.StatusForbidden, header: map[string]string{"X-RateLimit-Remaining": "0", "X-RateLimit-Reset": past}, limited: true},
+		{status: http.StatusForbidden, header: map[string]string{"X-RateLimit-Remaining": "0"}, limited: true, min: time.Minute, max: time.Minute},
+	}
+
+	for _, test := range tests {
+		r := &http.Response{StatusCode: test.status, Header: http.Header{}}
+
+		for key, value := range test.header {
+			r.Header.Set(key, value)
+		}
+
+		wait, limited := getRateLimitWait(r)
+
+		if limited != test.limited {
+			t.Errorf("expect limited to be %v for %d %v, got %v", test.limited, test.status, test.header, limited)
+		}
+
+		if wait < test.min || wait > test.max {
+			t.Errorf("expect wait in [%s, %s] for %d %v, got %s", test.min, test.max, test.status, test.header, wait)
+		}
+	}
+}
+
+func TestGitHubSourceGet(t *testing.T) {
+	const body = `[{"tag_name":"v1.4.2"}]`
+
+	var (
+		// whether the server rejects the requests by the rate limit
+		limited int32
+		// the requests with `If-None-Match`
+		revalidated int32
+	)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.LoadInt32(&limited) == 1 {
+			w.Header().Set("X-RateLimit-Remaining", "0")
+			w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(time.Now().Add(time.Hour).Unix(), 10))
+			http.Error(w, `{"message": "API rate limit exceeded"}`, http.StatusForbidden)
+			return
+		}
+
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			atomic.AddInt32(&revalidated, 1)
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+
+		w.Header().Set("ETag", `"v1"`)
+		_, _ = w.Write([]byte(body))
+	}))
+
+	defer server.Close()
+
+	home, clean := testutil.TempDir(t)
+	defer clean()
+
+	defer testutil.Setenv(t, map[string]string{
+		"DENOX_HOME":            home,
+		"DENOX_GITHUB_MAX_WAIT": "1s",
+		"GITHUB_TOKEN":          "",
+		"DENOX_GITHUB_TOKEN":    "",
+	})()
+
+	s := &githubSource{url: server.URL}
+
+	// the first request fills the cache
+	b, _, err := s.get(server.URL + "/releases")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(b) != body {
+		t.Fatalf("expect body `%s`, got `%s`", body, b)
+	}
+
+	// the second request is revalidated and responded with 304
+	b, _, err = s.get(server.URL + "/releases")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(b) != body {
+		t.Errorf("expect the cached body `%s` on 304, got `%s`", body, b)
+	}
+
+	if n := atomic.LoadInt32(&revalidated); n != 1 {
+		t.Errorf("expect 1 request with If-None-Match, got %d", n)
+	}
+
+	atomic.StoreInt32(&limited, 1)
+
+	// rate limited, use the cached response instead of waiting an hour for the reset
+	b, _, err = s.get(server.URL + "/releases")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(b) != body {
+		t.Errorf("expect the cached body `%s` when rate limited, got `%s`", body, b)
+	}
+
+	// rate limited without cache, a clear message instead of a bare 403
+	_, _, err = s.get(server.URL + "/releases/tags/v1.4.2")
+
+	if errors.Cause(err) != ErrRateLimited {
+		t.Fatalf("expect ErrRateLimited, got %v", err)
+	}
+
+	if msg := err.Error(); !strings.Contains(msg, "reset in") || !strings.Contains(msg, "GITHUB_TOKEN") {
+		t.Errorf("expect the message to tell the reset time and the token, got `%s`", msg)
+	}
+}
+
+func TestGitHubSourceGetRetryAfter(t *testing.T) {
+	var requests int32
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// the first request hits the secondary rate limit
+		if atomic.AddInt32(&requests, 1) == 1 {
+			w.Header().Set("Retry-After", "0")
+			http.Error(w, `{"message": "You have exceeded a secondary rate limit"}`, http.StatusForbidden)
+			return
+		}
+
+		_, _ = w.Write([]byte(`[]`))
+	}))
+
+	defer server.Close()
+
+	home, clean := testutil.TempDir(t)
+	defer clean()
+
+	defer testutil.Setenv(t, map[string]string{"DENOX_HOME": home, "DENOX_GITHUB_MAX_WAIT": "1s"})()
+
+	s := &githubSource{url: server.URL}
+
+	b, _, err := s.get(server.URL + "/releases")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(b) != "[]" {
+		t.Errorf("expect body `[]`, got `%s`", b)
+	}
+
+	if n := atomic.LoadInt32(&requests); n != 2 {
+		t.Errorf("expect 2 requests, got %d", n)
+	}
+}
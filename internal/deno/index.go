@@ -0,0 +1,143 @@
+package deno
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/axetroy/denox/internal/fs"
+	"github.com/pkg/errors"
+)
+
+const (
+	// how long the cached release index keeps fresh, can be overridden with environment variable `DENOX_INDEX_TTL`
+	DefaultIndexTTL = time.Hour
+)
+
+// the release index cached on disk
+type releaseIndex struct {
+	UpdatedAt time.Time `json:"updated_at"`
+	Releases  []Release `json:"releases"`
+}
+
+func (i *releaseIndex) fresh(ttl time.Duration) bool {
+	return time.Since(i.UpdatedAt) < ttl
+}
+
+// get the ttl of release index
+func getIndexTTL() time.Duration {
+	if s := os.Getenv("DENOX_INDEX_TTL"); s != "" {
+		if ttl, err := time.ParseDuration(s); err == nil {
+			return ttl
+		}
+	}
+
+	return DefaultIndexTTL
+}
+
+func getIndexFilepath() (string, error) {
+	cacheDir, err := getDenoCacheDir()
+
+	if err != nil {
+		return "", err
+	}
+
+	return path.Join(cacheDir, "index.json"), nil
+}
+
+// load the cached release index, returns nil if there is no cache
+func loadIndex(file string) (*releaseIndex, error) {
+	if exist, err := fs.PathExists(file); err != nil || !exist {
+		return nil, err
+	}
+
+	b, err := ioutil.ReadFile(file)
+
+	if err != nil {
+		return nil, errors.Wrapf(err, "read file `%s` fail", file)
+	}
+
+	var index releaseIndex
+
+	// a broken cache is the same as no cache
+	if err := json.Unmarshal(b, &index); err != nil || len(index.Releases) == 0 {
+		return nil, nil
+	}
+
+	return &index, nil
+}
+
+// save the release index to disk
+func saveIndex(file string, releases []Release) error {
+	b, err := json.Marshal(releaseIndex{
+		UpdatedAt: time.Now(),
+		Releases:  releases,
+	})
+
+	if err != nil {
+		return errors.Wrap(err, "encode JSON fail")
+	}
+
+	return fs.WriteFileAtomic(file, b)
+}
+
+// get the versions which have been installed in the data dir
+func getInstalledVersions() ([]string, error) {
+	dataDir, err := getDataDir()
+
+	if err != nil {
+		return nil, err
+	}
+
+	denoOS, err := getDenoOS()
+
+	if err != nil {
+		return nil, err
+	}
+
+	dirs, err := filepath.Glob(filepath.Join(dataDir, "deno_*"))
+
+	if err != nil {
+		return nil, err
+	}
+
+	versions := make([]string, 0)
+
+	for _, dir := range dirs {
+		if exist, err := fs.PathExists(filepath.Join(dir, "bin", ExecutableName(*denoOS))); err != nil {
+			return nil, err
+		} else if exist {
+			versions = append(versions, strings.TrimPrefix(filepath.Base(dir), "deno_"))
+		}
+	}
+
+	return versions, nil
+}
+
+// find the release in the cached release index, returns nil if not found.
+// it never fetch the remote
+func findCachedRelease(tag string) *Release {
+	indexFile, err := getIndexFilepath()
+
+	if err != nil {
+		return nil
+	}
+
+	index, err := loadIndex(indexFile)
+
+	if err != nil || index == nil {
+		return nil
+	}
+
+	for _, release := range index.Releases {
+		if release.Tag == tag {
+			return &release
+		}
+	}
+
+	return nil
+}
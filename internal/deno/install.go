@@ -0,0 +1,159 @@
+package deno
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+
+	"github.com/axetroy/denox/internal/archive"
+	"github.com/axetroy/denox/internal/fs"
+	"github.com/axetroy/denox/internal/logger"
+	"github.com/pkg/errors"
+)
+
+const (
+	stagingPrefix = ".staging-"
+)
+
+// install Deno into dstDir, the caller must hold the install lock.
+// everything is done in a staging dir then renamed to dstDir, so that other processes never see a partial installation
+func (d *Deno) install(dstDir string) error {
+	// the staging dir is next to the install dir rather than in it, so that the install dir is not created until it is complete
+	dataDir := filepath.Dir(d.InstallDir)
+	prefix := stagingPrefix + filepath.Base(d.InstallDir) + "-"
+
+	// the staging dirs left by the killed processes
+	if dirs, err := filepath.Glob(path.Join(dataDir, prefix+"*")); err == nil {
+		for _, dir := range dirs {
+			logger.Debugf("remove stale staging dir `%s`", dir)
+			_ = os.RemoveAll(dir)
+		}
+	}
+
+	if err := fs.EnsureDir(dataDir); err != nil {
+		return err
+	}
+
+	stagingDir, err := ioutil.TempDir(dataDir, prefix)
+
+	if err != nil {
+		return errors.Wrap(err, "create staging dir fail")
+	}
+
+	d.stagingDir = stagingDir
+
+	defer d.cleanStaging()
+
+	asset, err := getAssetName(d.Version, d.Os, d.Arch, findCachedRelease(d.Version))
+
+	if err != nil {
+		return err
+	}
+
+	if err := d.checkLibc(); err != nil {
+		return err
+	}
+
+	extractDir := path.Join(stagingDir, "extract")
+
+	files, err := d.downloadAndExtract(asset, extractDir)
+
+	// the asset name from the naming table may be wrong, pick one from the asset list of the release
+	if errors.Cause(err) == ErrAssetNotFound {
+		var fallback string
+
+		if fallback, err = d.fallbackAssetName(asset, err); err != nil {
+			return err
+		}
+
+		logger.Debugf("asset `%s` not found, download `%s` from the asset list of the release instead", asset, fallback)
+
+		asset = fallback
+		files, err = d.downloadAndExtract(asset, extractDir)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	// the release archive may contain other files, only the executable is installed
+	executable, err := archive.FindExecutable(files, ExecutableName(d.Os))
+
+	if err != nil {
+		return err
+	}
+
+	stagingBinDir := path.Join(stagingDir, "bin")
+
+	if err := fs.EnsureDir(stagingBinDir); err != nil {
+		return err
+	}
+
+	if err := os.Rename(path.Join(extractDir, executable), path.Join(stagingBinDir, ExecutableName(d.Os))); err != nil {
+		return errors.Wrapf(err, "move `%s` fail", executable)
+	}
+
+	// make sure is it is a executable file
+	if d.Os != OsWindows {
+		if err := os.Chmod(path.Join(stagingBinDir, ExecutableName(d.Os)), os.FileMode(0755)); err != nil {
+			return errors.Wrap(err, "set permission fail")
+		}
+	}
+
+	// it runs in the staging dir, the broken one is removed with it
+	if err := d.smokeTest(path.Join(stagingBinDir, ExecutableName(d.Os))); err != nil {
+		return err
+	}
+
+	// the manifest marks the installation is complete
+	if err := writeManifest(stagingBinDir, ExecutableName(d.Os), d.Version, asset); err != nil {
+		return errors.Wrap(err, "write install manifest fail")
+	}
+
+	// move the broken installation away, it will be removed with the staging dir
+	oldBinDir := path.Join(stagingDir, "old")
+
+	if exist, err := fs.PathExists(dstDir); err != nil {
+		return err
+	} else if exist {
+		if err := os.Rename(dstDir, oldBinDir); err != nil {
+			return errors.Wrapf(err, "move `%s` to `%s` fail", dstDir, oldBinDir)
+		}
+	}
+
+	if err := fs.EnsureDir(d.InstallDir); err != nil {
+		return err
+	}
+
+	if err := os.Rename(stagingBinDir, dstDir); err != nil {
+		// put the old one back
+		_ = os.Rename(oldBinDir, dstDir)
+		return errors.Wrapf(err, "move `%s` to `%s` fail", stagingBinDir, dstDir)
+	}
+
+	return nil
+}
+
+// get the asset name from the asset list of the release on GitHub, when the asset is not found on any mirror.
+// notFoundErr is returned if there is no other asset to try
+func (d *Deno) fallbackAssetName(asset string, notFoundErr error) (string, error) {
+	release, err := fetchGitHubRelease(d.Version)
+
+	if err != nil {
+		logger.Debugf("fetch the asset list of Deno %s fail: %s", d.Version, err)
+		return "", notFoundErr
+	}
+
+	fallback, err := getAssetName(d.Version, d.Os, d.Arch, release)
+
+	if err != nil {
+		return "", err
+	}
+
+	if fallback == asset {
+		return "", notFoundErr
+	}
+
+	return fallback, nil
+}
@@ -0,0 +1,313 @@
+package deno
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/axetroy/denox/internal/fs"
+	"github.com/axetroy/denox/internal/lock"
+	"github.com/axetroy/denox/internal/logger"
+	"github.com/axetroy/denox/internal/semver"
+	"github.com/pkg/errors"
+)
+
+var (
+	ErrNotInstalled = errors.New("not installed")
+)
+
+// Installation is an installed version of Deno
+type Installation struct {
+	Version    string `json:"version"`
+	Dir        string `json:"dir"`
+	Executable string `json:"executable"`
+	// bytes of the dir, including the module cache if it is the `DENO_DIR` of policy `per-version`
+	Size int64 `json:"size"`
+	// nil if it is installed by the old version of denox without the install manifest
+	InstalledAt *time.Time `json:"installed_at"`
+	// nil if it has never been used since it is installed
+	LastUsedAt *time.Time `json:"last_used_at"`
+}
+
+// ListInstallations returns the installed versions from the newest to the oldest
+func ListInstallations() ([]*Installation, error) {
+	dataDir, err := getDataDir()
+
+	if err != nil {
+		return nil, err
+	}
+
+	denoOS, err := getDenoOS()
+
+	if err != nil {
+		return nil, err
+	}
+
+	versions, err := getInstalledVersions()
+
+	if err != nil {
+		return nil, errors.Wrap(err, "get installed versions fail")
+	}
+
+	sortVersions(versions)
+
+	installations := make([]*Installation, 0, len(versions))
+
+	for _, version := range versions {
+		installation, err := getInstallation(dataDir, *denoOS, version)
+
+		if err != nil {
+			return nil, err
+		}
+
+		installations = append(installations, installation)
+	}
+
+	return installations, nil
+}
+
+func getInstallation(dataDir string, denoOS Os, version string) (*Installation, error) {
+	dir := filepath.Join(dataDir, "deno_"+version)
+	binDir := filepath.Join(dir, "bin")
+
+	installation := &Installation{
+		Version:    version,
+		Dir:        dir,
+		Executable: filepath.Join(binDir, ExecutableName(denoOS)),
+	}
+
+	if m, err := readManifest(binDir); err == nil {
+		installation.InstalledAt = &m.InstalledAt
+	}
+
+	size, err := getDirSize(dir)
+
+	if err != nil {
+		return nil, errors.Wrapf(err, "get size of `%s` fail", dir)
+	}
+
+	installation.Size = size
+
+	if file, err := getUsedFile(version); err == nil {
+		if stat, err := os.Stat(file); err == nil {
+			modTime := stat.ModTime()
+			installation.LastUsedAt = &modTime
+		}
+	}
+
+	return installation, nil
+}
+
+// the size of the files in the dir, the symlinks are not followed
+func getDirSize(dir string) (int64, error) {
+	var size int64
+
+	err := filepath.Walk(dir, func(file string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if info.Mode().IsRegular() {
+			size += info.Size()
+		}
+
+		return nil
+	})
+
+	return size, err
+}
+
+// sort the versions from the newest to the oldest, the ones which are not semver are at the end
+func sortVersions(versions []string) {
+	sort.SliceStable(versions, func(i, j int) bool {
+		a, errA := semver.Parse(versions[i])
+		b, errB := semver.Parse(versions[j])
+
+		switch {
+		case errA != nil && errB != nil:
+			return versions[i] < versions[j]
+		case errA != nil:
+			return false
+		case errB != nil:
+			return true
+		}
+
+		return b.LessThan(a)
+	})
+}
+
+// the file whose modification time is the last time the version is used
+func getUsedFile(version string) (string, error) {
+	stateDir, err := getStateDir()
+
+	if err != nil {
+		return "", err
+	}
+
+	usedDir := filepath.Join(stateDir, "used")
+
+	if err := fs.EnsureDir(usedDir); err != nil {
+		return "", errors.Wrap(err, "ensure used dir fail")
+	}
+
+	return filepath.Join(usedDir, "deno_"+version), nil
+}
+
+// MarkUsed record the time the version is used, see Installation.LastUsedAt
+func (d *Deno) MarkUsed() error {
+	file, err := getUsedFile(d.Version)
+
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+
+	if err := os.Chtimes(file, now, now); os.IsNotExist(err) {
+		if err := ioutil.WriteFile(file, nil, 0644); err != nil {
+			return errors.Wrapf(err, "write file `%s` fail", file)
+		}
+	} else if err != nil {
+		return errors.Wrapf(err, "change times of file `%s` fail", file)
+	}
+
+	return nil
+}
+
+// Uninstall remove the installation of the version, including the module cache if it is the `DENO_DIR` of policy `per-version`
+func Uninstall(version string) (*Installation, error) {
+	if v, err := semver.Parse(version); err == nil {
+		version = v.String()
+	}
+
+	dataDir, err := getDataDir()
+
+	if err != nil {
+		return nil, err
+	}
+
+	denoOS, err := getDenoOS()
+
+	if err != nil {
+		return nil, err
+	}
+
+	dir := filepath.Join(dataDir, "deno_"+version)
+
+	if exist, err := fs.PathExists(dir); err != nil {
+		return nil, errors.Wrapf(err, "stat file `%s` fail", dir)
+	} else if !exist {
+		return nil, errors.Wrapf(ErrNotInstalled, "Deno %s", version)
+	}
+
+	lockFile, err := getLockFile("deno_" + version)
+
+	if err != nil {
+		return nil, err
+	}
+
+	l, err := lock.Acquire(lockFile)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		if err := l.Release(); err != nil {
+			logger.Debugf("%s", err)
+		}
+	}()
+
+	installation, err := getInstallation(dataDir, *denoOS, version)
+
+	if err != nil {
+		return nil, err
+	}
+
+	// move it out of the way first, so that no one sees a partial installation
+	trash := filepath.Join(dataDir, fmt.Sprintf(".uninstall-%s-%d", version, os.Getpid()))
+
+	if err := os.Rename(dir, trash); err != nil {
+		return nil, errors.Wrapf(err, "uninstall Deno %s fail", version)
+	}
+
+	if err := os.RemoveAll(trash); err != nil {
+		return nil, errors.Wrapf(err, "remove dir `%s` fail", trash)
+	}
+
+	if file, err := getUsedFile(version); err == nil {
+		_ = os.Remove(file)
+	}
+
+	return installation, nil
+}
+
+// MatchInstalledVersion find the newest installed version which satisfies the spec, it never fetch the remote
+func MatchInstalledVersion(spec string) (string, error) {
+	r, err := semver.ParseRange(spec)
+
+	if err != nil {
+		return "", err
+	}
+
+	installed, err := getInstalledVersions()
+
+	if err != nil {
+		return "", errors.Wrap(err, "get installed versions fail")
+	}
+
+	releases := make([]Release, 0, len(installed))
+
+	for _, tag := range installed {
+		releases = append(releases, Release{Tag: tag})
+	}
+
+	return matchVersion(r, releases)
+}
+
+// ListReleases returns the releases of Deno from the cached release index or the release sources.
+// the stale cache is used if the sources are unavailable
+func ListReleases() ([]Release, error) {
+	indexFile, err := getIndexFilepath()
+
+	if err != nil {
+		return nil, err
+	}
+
+	index, err := loadIndex(indexFile)
+
+	if err != nil {
+		return nil, errors.Wrap(err, "load release index fail")
+	}
+
+	if index != nil && index.fresh(getIndexTTL()) {
+		logger.Debugf("use cached release index `%s`", indexFile)
+		return index.Releases, nil
+	}
+
+	sources, err := getReleaseSources()
+
+	if err != nil {
+		return nil, err
+	}
+
+	releases, fetchErr := fetchReleases(sources)
+
+	if fetchErr == nil {
+		if err := saveIndex(indexFile, releases); err != nil {
+			logger.Warnf("cache release index fail: %s", err)
+		}
+
+		return releases, nil
+	}
+
+	if index != nil {
+		logger.Warnf("fetch Deno versions fail, use release index cached at %s: %s", index.UpdatedAt.Format(time.RFC3339), fetchErr)
+		return index.Releases, nil
+	}
+
+	return nil, errors.Wrap(fetchErr, "fetch Deno versions fail")
+}
@@ -0,0 +1,111 @@
+package deno
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/axetroy/denox/internal/testutil"
+	"github.com/pkg/errors"
+)
+
+func TestSortVersions(t *testing.T) {
+	versions := []string{"v1.4.2", "canary", "v0.40.0", "v1.10.0", "v2.0.0-rc.1", "nightly", "v2.0.0", "v1.4.10"}
+
+	sortVersions(versions)
+
+	expected := []string{"v2.0.0", "v2.0.0-rc.1", "v1.10.0", "v1.4.10", "v1.4.2", "v0.40.0", "canary", "nightly"}
+
+	if !reflect.DeepEqual(versions, expected) {
+		t.Errorf("expect %v, got %v", expected, versions)
+	}
+}
+
+func TestUninstall(t *testing.T) {
+	home, clean := testutil.TempDir(t)
+	defer clean()
+
+	defer testutil.Setenv(t, map[string]string{"DENOX_HOME": home})()
+
+	denoOS, err := getDenoOS()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, version := range []string{"v1.4.2", "v1.10.0"} {
+		binDir := filepath.Join(home, "deno_"+version, "bin")
+
+		if err := os.MkdirAll(binDir, 0755); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := ioutil.WriteFile(filepath.Join(binDir, ExecutableName(*denoOS)), []byte("deno binary"), 0755); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := writeManifest(binDir, ExecutableName(*denoOS), version, ""); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := (&Deno{Version: version}).MarkUsed(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	installations, err := ListInstallations()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(installations) != 2 || installations[0].Version != "v1.10.0" || installations[1].Version != "v1.4.2" {
+		t.Fatalf("expect installations v1.10.0 and v1.4.2, got %+v", installations)
+	}
+
+	if installation := installations[1]; installation.Size <= int64(len("deno binary")) || installation.InstalledAt == nil || installation.LastUsedAt == nil {
+		t.Errorf("expect the size and the times of the installation, got %+v", installation)
+	}
+
+	// the version without the prefix `v`
+	installation, err := Uninstall("1.4.2")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if installation.Version != "v1.4.2" {
+		t.Errorf("expect uninstalled v1.4.2, got %s", installation.Version)
+	}
+
+	if _, err := os.Stat(filepath.Join(home, "deno_v1.4.2")); !os.IsNotExist(err) {
+		t.Errorf("expect the install dir to be removed, got %v", err)
+	}
+
+	if file, err := getUsedFile("v1.4.2"); err != nil {
+		t.Fatal(err)
+	} else if _, err := os.Stat(file); !os.IsNotExist(err) {
+		t.Errorf("expect the used mark to be removed, got %v", err)
+	}
+
+	// nothing left in the data dir, eg the trash
+	if matches, _ := filepath.Glob(filepath.Join(home, ".uninstall-*")); len(matches) != 0 {
+		t.Errorf("expect no trash left, got %v", matches)
+	}
+
+	if _, err := Uninstall("v1.4.2"); errors.Cause(err) != ErrNotInstalled {
+		t.Errorf("expect ErrNotInstalled, got %v", err)
+	}
+
+	installations, err = ListInstallations()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(installations) != 1 || installations[0].Version != "v1.10.0" {
+		t.Errorf("expect installation v1.10.0, got %+v", installations)
+	}
+}
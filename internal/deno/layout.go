@@ -0,0 +1,188 @@
+package deno
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/axetroy/denox/internal/dirs"
+	"github.com/axetroy/denox/internal/fs"
+	"github.com/axetroy/denox/internal/lock"
+	"github.com/axetroy/denox/internal/logger"
+	"github.com/pkg/errors"
+)
+
+var migrateOnce sync.Once
+
+// get the dir where Deno installed
+func getDataDir() (string, error) {
+	layout, err := dirs.Get()
+
+	if err != nil {
+		return "", err
+	}
+
+	migrate(layout)
+
+	return layout.Data, nil
+}
+
+// get the dir of the global default version
+func getConfigDir() (string, error) {
+	layout, err := dirs.Get()
+
+	if err != nil {
+		return "", err
+	}
+
+	migrate(layout)
+
+	return layout.Config, nil
+}
+
+// get cache dir for deno
+func getDenoCacheDir() (string, error) {
+	layout, err := dirs.Get()
+
+	if err != nil {
+		return "", err
+	}
+
+	if err = fs.EnsureDir(layout.Cache); err != nil {
+		return "", errors.Wrap(err, "ensure denox cache dir fail")
+	}
+
+	return layout.Cache, nil
+}
+
+// get state dir for denox
+func getStateDir() (string, error) {
+	layout, err := dirs.Get()
+
+	if err != nil {
+		return "", err
+	}
+
+	if err = fs.EnsureDir(layout.State); err != nil {
+		return "", errors.Wrap(err, "ensure denox state dir fail")
+	}
+
+	return layout.State, nil
+}
+
+// get the lock file of the name in the state dir
+func getLockFile(name string) (string, error) {
+	stateDir, err := getStateDir()
+
+	if err != nil {
+		return "", err
+	}
+
+	lockDir := filepath.Join(stateDir, "locks")
+
+	if err := fs.EnsureDir(lockDir); err != nil {
+		return "", errors.Wrap(err, "ensure lock dir fail")
+	}
+
+	return filepath.Join(lockDir, name+".lock"), nil
+}
+
+// migrate the legacy dir once in the process
+func migrate(layout *dirs.Layout) {
+	migrateOnce.Do(func() {
+		if err := migrateLegacyHome(layout); err != nil {
+			logger.Warnf("%s", err)
+		}
+	})
+}
+
+// move the installations and the global default version from `$HOME/.denox`, where denox stored everything before.
+// it does nothing if DENOX_HOME is set, set `DENOX_HOME=$HOME/.denox` to keep using the legacy dir
+func migrateLegacyHome(layout *dirs.Layout) (err error) {
+	if os.Getenv("DENOX_HOME") != "" {
+		return nil
+	}
+
+	homeDir, err := os.UserHomeDir()
+
+	if err != nil {
+		return nil
+	}
+
+	legacyHome := filepath.Join(homeDir, ".denox")
+
+	if exist, err := fs.PathExists(legacyHome); err != nil || !exist {
+		return nil
+	}
+
+	lockFile, err := getLockFile("migrate")
+
+	if err != nil {
+		return errors.Wrap(err, "migrate legacy dir fail")
+	}
+
+	l, err := lock.Acquire(lockFile)
+
+	if err != nil {
+		return errors.Wrap(err, "migrate legacy dir fail")
+	}
+
+	defer func() {
+		if releaseErr := l.Release(); releaseErr != nil && err == nil {
+			err = releaseErr
+		}
+	}()
+
+	files, err := ioutil.ReadDir(legacyHome)
+
+	// another process has migrated it while we were waiting for the lock
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return errors.Wrapf(err, "read legacy dir `%s` fail", legacyHome)
+	}
+
+	for _, file := range files {
+		var dst string
+
+		switch {
+		case file.IsDir() && strings.HasPrefix(file.Name(), "deno_"):
+			dst = filepath.Join(layout.Data, file.Name())
+		case !file.IsDir() && file.Name() == "version":
+			dst = filepath.Join(layout.Config, file.Name())
+		default:
+			continue
+		}
+
+		src := filepath.Join(legacyHome, file.Name())
+
+		if exist, err := fs.PathExists(dst); err != nil {
+			return errors.Wrapf(err, "stat file `%s` fail", dst)
+		} else if exist {
+			logger.Debugf("`%s` exists, skip migrating `%s`", dst, src)
+			continue
+		}
+
+		if err := fs.EnsureDir(filepath.Dir(dst)); err != nil {
+			return errors.Wrapf(err, "ensure dir `%s` fail", filepath.Dir(dst))
+		}
+
+		// the lock file is in the state dir now
+		_ = os.Remove(filepath.Join(src, ".install.lock"))
+
+		if err := os.Rename(src, dst); err != nil {
+			return errors.Wrapf(err, "migrate `%s` to `%s` fail, move it manually or set `DENOX_HOME=%s`", src, dst, legacyHome)
+		}
+
+		logger.Debugf("migrate `%s` to `%s`", src, dst)
+	}
+
+	// remove the legacy dir if nothing left, keep the files we do not know
+	if err := os.Remove(legacyHome); err != nil {
+		logger.Debugf("keep legacy dir `%s`: %s", legacyHome, err)
+	}
+
+	return nil
+}
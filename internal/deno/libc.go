@@ -0,0 +1,91 @@
+package deno
+
+import (
+	"os"
+
+	"github.com/axetroy/denox/internal/libc"
+	"github.com/axetroy/denox/internal/logger"
+	"github.com/axetroy/denox/internal/semver"
+	"github.com/pkg/errors"
+)
+
+var (
+	ErrUnsupportedLibc = errors.New("not support your libc")
+)
+
+// the min glibc version of the Linux binary, since the Deno version.
+// add an entry when the build environment of Deno raises the requirement, the later entry overrides the earlier one
+var glibcRequirements = []struct {
+	since string
+	// empty for all archs
+	arch  Arch
+	glibc string
+}{
+	// eg `GLIBC_2.18' not found` on CentOS 7
+	{since: "v1.0.0", glibc: "2.18"},
+	// the first Linux arm64 release, built for Ubuntu 18.04
+	{since: "v1.41.0", arch: Archarm64, glibc: "2.27"},
+	// eg `GLIBC_2.27' not found` on Amazon Linux 2
+	{since: "v2.0.0", glibc: "2.27"},
+}
+
+// get the min glibc version required by the Deno version on the arch, empty if no requirement
+func getGlibcRequirement(version string, arch Arch) string {
+	v, err := semver.Parse(version)
+
+	if err != nil {
+		return ""
+	}
+
+	required := ""
+
+	for _, r := range glibcRequirements {
+		if r.arch != "" && r.arch != arch {
+			continue
+		}
+
+		if since, _ := semver.Parse(r.since); !v.LessThan(since) {
+			required = r.glibc
+		}
+	}
+
+	return required
+}
+
+// make sure the Linux binary of Deno can run on the system, so that we do not download it for nothing.
+// skip it with environment variable `DENOX_SKIP_LIBC_CHECK`
+func (d *Deno) checkLibc() error {
+	if d.Os != OsLinux || os.Getenv("DENOX_SKIP_LIBC_CHECK") != "" {
+		return nil
+	}
+
+	info, err := libc.Detect()
+
+	// let the smoke test find out
+	if err != nil {
+		logger.Debugf("%s", err)
+		return nil
+	}
+
+	logger.Debugf("detect %s, the interpreter is `%s`", info, info.Interpreter)
+
+	switch info.Family {
+	case libc.Musl:
+		if info.GlibcCompat {
+			logger.Warnf("Deno is built for glibc, it may not work with the glibc compatibility layer of musl")
+			return nil
+		}
+
+		return errors.Wrapf(ErrUnsupportedLibc, "Deno %s is built for glibc but your system uses musl (eg Alpine). "+
+			"use a glibc based image, or install the glibc compatibility layer then set environment variable `DENOX_SKIP_LIBC_CHECK=1`", d.Version)
+	case libc.Glibc:
+		required := getGlibcRequirement(d.Version, d.Arch)
+
+		if required != "" && info.Version != "" && libc.CompareVersion(info.Version, required) < 0 {
+			return errors.Wrapf(ErrUnsupportedLibc, "Deno %s requires glibc %s or later but your system has glibc %s. "+
+				"use an older version of Deno or upgrade your system. set environment variable `DENOX_SKIP_LIBC_CHECK=1` if it works anyway", d.Version, required, info.Version)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,27 @@
+package deno
+
+import "testing"
+
+func TestGetGlibcRequirement(t *testing.T) {
+	tests := []struct {
+		version  string
+		arch     Arch
+		expected string
+	}{
+		{"v0.42.0", Archx64, ""},
+		{"v1.0.0", Archx64, "2.18"},
+		{"v1.46.3", Archx64, "2.18"},
+		{"v1.40.0", Archarm64, "2.18"},
+		{"v1.41.0", Archarm64, "2.27"},
+		{"v1.46.3", Archarm64, "2.27"},
+		{"v2.0.0", Archx64, "2.27"},
+		{"v2.0.0-rc.1", Archx64, "2.18"},
+		{"latest", Archx64, ""},
+	}
+
+	for _, test := range tests {
+		if actual := getGlibcRequirement(test.version, test.arch); actual != test.expected {
+			t.Errorf("expect glibc `%s` for %s on %s, got `%s`", test.expected, test.version, test.arch, actual)
+		}
+	}
+}
@@ -0,0 +1,135 @@
+package deno
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path"
+	"time"
+
+	"github.com/axetroy/denox/internal/checksum"
+	"github.com/axetroy/denox/internal/fs"
+	"github.com/pkg/errors"
+)
+
+const (
+	// the manifest in the bin dir, it is written only after a successful installation
+	manifestFilename = ".denox-install.json"
+)
+
+var (
+	ErrNoManifest     = errors.New("install manifest not found")
+	ErrCorruptInstall = errors.New("corrupt installation")
+)
+
+// the manifest of an installation
+type manifest struct {
+	Version     string    `json:"version"`
+	Asset       string    `json:"asset"`
+	Size        int64     `json:"size"`
+	Sha256      string    `json:"sha256"`
+	ModTime     time.Time `json:"mod_time"`
+	InstalledAt time.Time `json:"installed_at"`
+}
+
+// write the manifest of the executable file into the bin dir
+func writeManifest(binDir string, executableName string, version string, asset string) error {
+	executablePath := path.Join(binDir, executableName)
+
+	stat, err := os.Stat(executablePath)
+
+	if err != nil {
+		return errors.Wrapf(err, "stat file `%s` fail", executablePath)
+	}
+
+	sum, err := checksum.File(executablePath)
+
+	if err != nil {
+		return err
+	}
+
+	b, err := json.MarshalIndent(manifest{
+		Version:     version,
+		Asset:       asset,
+		Size:        stat.Size(),
+		Sha256:      sum,
+		ModTime:     stat.ModTime(),
+		InstalledAt: time.Now(),
+	}, "", "  ")
+
+	if err != nil {
+		return errors.Wrap(err, "encode JSON fail")
+	}
+
+	manifestFilepath := path.Join(binDir, manifestFilename)
+
+	if err := ioutil.WriteFile(manifestFilepath, b, 0644); err != nil {
+		return errors.Wrapf(err, "write file `%s` fail", manifestFilepath)
+	}
+
+	return nil
+}
+
+// read the manifest in the bin dir
+func readManifest(binDir string) (*manifest, error) {
+	manifestFilepath := path.Join(binDir, manifestFilename)
+
+	if exist, err := fs.PathExists(manifestFilepath); err != nil {
+		return nil, err
+	} else if !exist {
+		return nil, ErrNoManifest
+	}
+
+	b, err := ioutil.ReadFile(manifestFilepath)
+
+	if err != nil {
+		return nil, errors.Wrapf(err, "read file `%s` fail", manifestFilepath)
+	}
+
+	var m manifest
+
+	if err := json.Unmarshal(b, &m); err != nil {
+		return nil, errors.Wrapf(ErrCorruptInstall, "invalid manifest `%s`", manifestFilepath)
+	}
+
+	return &m, nil
+}
+
+// check the installation in the bin dir with its manifest.
+// it runs on every run of Deno, so the hash of the 100 MB executable is only computed if the modification time changed,
+// unless verify is true. an overwrite with the same size and modification time is detected with verify only
+func checkInstall(binDir string, executableName string, verify bool) error {
+	m, err := readManifest(binDir)
+
+	if err != nil {
+		return err
+	}
+
+	executablePath := path.Join(binDir, executableName)
+
+	stat, err := os.Stat(executablePath)
+
+	if os.IsNotExist(err) {
+		return errors.Wrapf(ErrCorruptInstall, "`%s` is missing", executablePath)
+	} else if err != nil {
+		return errors.Wrapf(err, "stat file `%s` fail", executablePath)
+	}
+
+	if stat.Size() != m.Size {
+		return errors.Wrapf(ErrCorruptInstall, "`%s` expected %d bytes but got %d", executablePath, m.Size, stat.Size())
+	}
+
+	if !verify && stat.ModTime().Equal(m.ModTime) {
+		return nil
+	}
+
+	if err := checksum.Verify(executablePath, m.Sha256); err != nil {
+		if errors.Cause(err) == checksum.ErrMismatch {
+			return errors.Wrap(ErrCorruptInstall, err.Error())
+		}
+
+		return err
+	}
+
+	return nil
+}
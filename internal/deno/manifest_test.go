@@ -0,0 +1,116 @@
+package deno
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/axetroy/denox/internal/testutil"
+	"github.com/pkg/errors"
+)
+
+func TestCheckInstall(t *testing.T) {
+	const executableName = "deno"
+
+	tests := []struct {
+		name string
+		// break the installation after the manifest is written
+		breakInstall func(binDir string) error
+		verify       bool
+		err          error
+	}{
+		{name: "intact", breakInstall: func(binDir string) error { return nil }},
+		{name: "intact and verify", breakInstall: func(binDir string) error { return nil }, verify: true},
+		{
+			name: "manifest missing",
+			breakInstall: func(binDir string) error {
+				return os.Remove(filepath.Join(binDir, manifestFilename))
+			},
+			err: ErrNoManifest,
+		},
+		{
+			name: "manifest broken",
+			breakInstall: func(binDir string) error {
+				return ioutil.WriteFile(filepath.Join(binDir, manifestFilename), []byte("{"), 0644)
+			},
+			err: ErrCorruptInstall,
+		},
+		{
+			name: "executable missing",
+			breakInstall: func(binDir string) error {
+				return os.Remove(filepath.Join(binDir, executableName))
+			},
+			err: ErrCorruptInstall,
+		},
+		{
+			name: "executable truncated",
+			breakInstall: func(binDir string) error {
+				return os.Truncate(filepath.Join(binDir, executableName), 4)
+			},
+			err: ErrCorruptInstall,
+		},
+		{
+			name: "hash mismatch",
+			breakInstall: func(binDir string) error {
+				return ioutil.WriteFile(filepath.Join(binDir, executableName), []byte("evil binary"), 0755)
+			},
+			err: ErrCorruptInstall,
+		},
+		{
+			// the same size and modification time, only detected with verify
+			name: "overwritten in place",
+			breakInstall: func(binDir string) error {
+				file := filepath.Join(binDir, executableName)
+
+				stat, err := os.Stat(file)
+
+				if err != nil {
+					return err
+				}
+
+				if err := ioutil.WriteFile(file, []byte("evil binary"), 0755); err != nil {
+					return err
+				}
+
+				return os.Chtimes(file, stat.ModTime(), stat.ModTime())
+			},
+			verify: true,
+			err:    ErrCorruptInstall,
+		},
+	}
+
+	for _, test := range tests {
+		binDir, clean := testutil.TempDir(t)
+
+		err := ioutil.WriteFile(filepath.Join(binDir, executableName), []byte("deno binary"), 0755)
+
+		if err == nil {
+			// make sure that the modification time changes when the file is rewritten
+			past := time.Now().Add(-time.Hour)
+			err = os.Chtimes(filepath.Join(binDir, executableName), past, past)
+		}
+
+		if err == nil {
+			err = writeManifest(binDir, executableName, "v1.4.2", "deno-x86_64-unknown-linux-gnu.zip")
+		}
+
+		if err == nil {
+			err = test.breakInstall(binDir)
+		}
+
+		if err != nil {
+			clean()
+			t.Fatal(err)
+		}
+
+		err = checkInstall(binDir, executableName, test.verify)
+
+		clean()
+
+		if errors.Cause(err) != test.err {
+			t.Errorf("%s: expect %v, got %v", test.name, test.err, err)
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package deno
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/axetroy/denox/internal/config"
+	"github.com/axetroy/denox/internal/download"
+	"github.com/axetroy/denox/internal/logger"
+	"github.com/pkg/errors"
+)
+
+const (
+	// the default download URL template
+	DefaultMirror = "https://github.com/denoland/deno/releases/download/{version}/{asset}"
+)
+
+// Mirror is a URL template to download the release asset.
+// the placeholders `{version}`, `{os}`, `{arch}` and `{asset}` will be replaced
+type Mirror string
+
+// URL returns the download URL of the asset
+func (m Mirror) URL(version string, denoOs Os, denoArch Arch, asset string) string {
+	return strings.NewReplacer(
+		"{version}", version,
+		"{os}", string(denoOs),
+		"{arch}", string(denoArch),
+		"{asset}", asset,
+	).Replace(string(m))
+}
+
+// ParseMirrors parse a comma separated list of mirrors.
+// the item is a URL template or a base URL, eg `https://example.com/deno` is the same as `https://example.com/deno/{version}/{asset}`
+func ParseMirrors(s string) []Mirror {
+	mirrors := make([]Mirror, 0)
+
+	for _, item := range strings.Split(s, ",") {
+		item = strings.TrimSpace(item)
+
+		if item == "" {
+			continue
+		}
+
+		if !strings.Contains(item, "{") {
+			item = strings.TrimSuffix(item, "/") + "/{version}/{asset}"
+		}
+
+		mirrors = append(mirrors, Mirror(item))
+	}
+
+	return mirrors
+}
+
+// get mirrors from config `mirror`, they are tried in order
+func getMirrors() []Mirror {
+	mirrors := ParseMirrors(config.Default().Get("mirror"))
+
+	if len(mirrors) == 0 {
+		mirrors = append(mirrors, DefaultMirror)
+	}
+
+	return mirrors
+}
+
+// download the asset from the mirrors in order until one of them succeeds.
+// it returns ErrAssetNotFound if every mirror responds 404
+func (d *Deno) downloadFromMirrors(filepath string, asset string) error {
+	messages := make([]string, 0)
+	notFound := true
+
+	downloader, err := getDownloader()
+
+	if err != nil {
+		return err
+	}
+
+	for _, mirror := range getMirrors() {
+		downloadURL := mirror.URL(d.Version, d.Os, d.Arch, asset)
+
+		logger.Debugf("download `%s`", downloadURL)
+
+		if err := downloader.Download(filepath, downloadURL); err != nil {
+			logger.Debugf("download `%s` fail: %s", downloadURL, err)
+			messages = append(messages, fmt.Sprintf("%s: %s", downloadURL, err))
+			notFound = notFound && isNotFound(err)
+			continue
+		}
+
+		return nil
+	}
+
+	if notFound {
+		return errors.Wrap(ErrAssetNotFound, strings.Join(messages, "; "))
+	}
+
+	return errors.New(strings.Join(messages, "; "))
+}
+
+// whether the server responds 404
+func isNotFound(err error) bool {
+	statusErr, ok := errors.Cause(err).(*download.StatusError)
+
+	return ok && statusErr.StatusCode == http.StatusNotFound
+}
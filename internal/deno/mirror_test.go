@@ -0,0 +1,147 @@
+package deno
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/axetroy/denox/internal/testutil"
+	"github.com/pkg/errors"
+)
+
+func TestParseMirrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []Mirror
+	}{
+		{input: "", expected: []Mirror{}},
+		{input: " , ", expected: []Mirror{}},
+		{input: "https://example.com/deno", expected: []Mirror{"https://example.com/deno/{version}/{asset}"}},
+		{input: "https://example.com/deno/", expected: []Mirror{"https://example.com/deno/{version}/{asset}"}},
+		{input: "https://example.com/{os}/{arch}/{asset}", expected: []Mirror{"https://example.com/{os}/{arch}/{asset}"}},
+		{
+			input:    "https://a.example.com/, https://b.example.com/{version}/{asset}",
+			expected: []Mirror{"https://a.example.com/{version}/{asset}", "https://b.example.com/{version}/{asset}"},
+		},
+	}
+
+	for _, test := range tests {
+		actual := ParseMirrors(test.input)
+
+		if !reflect.DeepEqual(actual, test.expected) {
+			t.Errorf("expect %v for `%s`, got %v", test.expected, test.input, actual)
+		}
+	}
+}
+
+func TestMirrorURL(t *testing.T) {
+	tests := []struct {
+		mirror   Mirror
+		expected string
+	}{
+		{mirror: DefaultMirror, expected: "https://github.com/denoland/deno/releases/download/v1.4.2/deno-x86_64-unknown-linux-gnu.zip"},
+		{mirror: "http://127.0.0.1/{version}/{os}/{arch}/{asset}", expected: "http://127.0.0.1/v1.4.2/linux/x64/deno-x86_64-unknown-linux-gnu.zip"},
+		{mirror: "http://127.0.0.1/deno-{os}-{arch}-{version}.zip", expected: "http://127.0.0.1/deno-linux-x64-v1.4.2.zip"},
+	}
+
+	for _, test := range tests {
+		actual := test.mirror.URL("v1.4.2", OsLinux, Archx64, "deno-x86_64-unknown-linux-gnu.zip")
+
+		if actual != test.expected {
+			t.Errorf("expect `%s` for `%s`, got `%s`", test.expected, test.mirror, actual)
+		}
+	}
+}
+
+func TestDownloadFromMirrors(t *testing.T) {
+	const content = "deno binary"
+
+	// the path of the request, the status of the response
+	handler := func(routes map[string]int) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			status, ok := routes[r.URL.Path]
+
+			if !ok {
+				http.NotFound(w, r)
+				return
+			}
+
+			if status != http.StatusOK {
+				http.Error(w, http.StatusText(status), status)
+				return
+			}
+
+			_, _ = w.Write([]byte(content))
+		})
+	}
+
+	broken := httptest.NewServer(handler(map[string]int{"/v1.4.2/linux/x64/deno-x86_64-unknown-linux-gnu.zip": http.StatusInternalServerError}))
+	defer broken.Close()
+
+	empty := httptest.NewServer(handler(map[string]int{}))
+	defer empty.Close()
+
+	good := httptest.NewServer(handler(map[string]int{"/deno/v1.4.2/deno-x86_64-unknown-linux-gnu.zip": http.StatusOK}))
+	defer good.Close()
+
+	home, clean := testutil.TempDir(t)
+	defer clean()
+
+	tests := []struct {
+		name     string
+		mirror   string
+		notFound bool
+		err      bool
+	}{
+		{name: "fallback to the next mirror", mirror: broken.URL + "/{version}/{os}/{arch}/{asset}, " + good.URL + "/deno/"},
+		{name: "fallback to the next mirror if not found", mirror: empty.URL + "," + good.URL + "/deno"},
+		{name: "not found on every mirror", mirror: empty.URL + "/{version}/{asset}," + empty.URL + "/{os}/{asset}", err: true, notFound: true},
+		{name: "not found and server error", mirror: empty.URL + "," + broken.URL + "/{version}/{os}/{arch}/{asset}", err: true},
+	}
+
+	for _, test := range tests {
+		restore := testutil.Setenv(t, map[string]string{
+			"DENOX_HOME":                 home,
+			"DENOX_MIRROR":               test.mirror,
+			"DENOX_DOWNLOAD_RETRIES":     "0",
+			"DENOX_DOWNLOAD_CONNECTIONS": "1",
+			"DENOX_PROGRESS":             "none",
+		})
+
+		d := &Deno{Version: "v1.4.2", Os: OsLinux, Arch: Archx64}
+
+		file := filepath.Join(home, "deno.zip")
+
+		err := d.downloadFromMirrors(file, "deno-x86_64-unknown-linux-gnu.zip")
+
+		restore()
+
+		if test.err {
+			if err == nil {
+				t.Errorf("%s: expect error, got nil", test.name)
+			} else if notFound := errors.Cause(err) == ErrAssetNotFound; notFound != test.notFound {
+				t.Errorf("%s: expect ErrAssetNotFound to be %v, got %v", test.name, test.notFound, err)
+			}
+
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("%s: %v", test.name, err)
+			continue
+		}
+
+		b, err := ioutil.ReadFile(file)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if string(b) != content {
+			t.Errorf("%s: expect content `%s`, got `%s`", test.name, content, b)
+		}
+	}
+}
@@ -0,0 +1,173 @@
+package deno
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/axetroy/denox/internal/config"
+	"github.com/axetroy/denox/internal/fs"
+	"github.com/axetroy/denox/internal/logger"
+	"github.com/pkg/errors"
+)
+
+const (
+	// a file only contains the version spec, eg `^1.2`
+	PinFilename = ".deno-version"
+	// the project config, the version spec is in the `version` key
+	RcFilename = config.ProjectFilename
+)
+
+// where the version spec comes from
+const (
+	VersionFromFlag    = "flag --deno-version"
+	VersionFromEnv     = "environment variable DENO_VERSION"
+	VersionFromPinFile = "pin file"
+	VersionFromGlobal  = "global default"
+	VersionFromLatest  = "latest"
+)
+
+// LookupVersion find out the version spec to use with following order:
+// 1. environment variable `DENO_VERSION`
+// 2. pin file `.deno-version` or `.denoxrc` walking up from cwd
+// 3. global default, the `version` key of the user config or the system config, then the `version` file in the config dir
+// 4. latest version
+// it returns nil spec for the latest version
+func LookupVersion(cwd string) (spec *string, from string, err error) {
+	if v := strings.TrimSpace(os.Getenv("DENO_VERSION")); v != "" {
+		logger.Debugf("use version `%s` from %s", v, VersionFromEnv)
+		return &v, VersionFromEnv, nil
+	}
+
+	logger.Debugf("environment variable DENO_VERSION is not set")
+
+	if v, file, err := findPinFile(cwd); err != nil {
+		return nil, "", errors.Wrap(err, "find pin file fail")
+	} else if v != nil {
+		logger.Debugf("use version `%s` from %s `%s`", *v, VersionFromPinFile, file)
+		return v, VersionFromPinFile, nil
+	}
+
+	logger.Debugf("no pin file found from `%s`", cwd)
+
+	if v, err := LookupGlobalVersion(); err != nil {
+		return nil, "", err
+	} else if v != nil {
+		return v, VersionFromGlobal, nil
+	}
+
+	return nil, VersionFromLatest, nil
+}
+
+// LookupGlobalVersion find out the global default version spec,
+// the `version` key of the user config or the system config, then the `version` file in the config dir.
+// it returns nil spec if there is no global default
+func LookupGlobalVersion() (*string, error) {
+	files := config.Default().Files
+
+	// the user config overrides the system config
+	for i := len(files) - 1; i >= 0; i-- {
+		file := files[i]
+
+		if file.Layer != config.LayerUser && file.Layer != config.LayerSystem {
+			continue
+		}
+
+		if v := file.Values["version"]; v != "" {
+			logger.Debugf("use version `%s` from %s config `%s`", v, file.Layer, file.Path)
+			return &v, nil
+		}
+	}
+
+	configDir, err := getConfigDir()
+
+	if err != nil {
+		return nil, err
+	}
+
+	globalFile := filepath.Join(configDir, "version")
+
+	if v, err := readPinFile(globalFile); err != nil {
+		return nil, errors.Wrapf(err, "read global default `%s` fail", globalFile)
+	} else if v != nil {
+		logger.Debugf("use version `%s` from %s `%s`", *v, VersionFromGlobal, globalFile)
+		return v, nil
+	}
+
+	logger.Debugf("no global default found at `%s`, use the latest version", globalFile)
+
+	return nil, nil
+}
+
+// walk up from dir and returns the version spec of the first pin file found
+func findPinFile(dir string) (*string, string, error) {
+	dir, err := filepath.Abs(dir)
+
+	if err != nil {
+		return nil, "", err
+	}
+
+	for {
+		pinFile := filepath.Join(dir, PinFilename)
+
+		if v, err := readPinFile(pinFile); err != nil {
+			return nil, "", errors.Wrapf(err, "read pin file `%s` fail", pinFile)
+		} else if v != nil {
+			return v, pinFile, nil
+		}
+
+		rcFile := filepath.Join(dir, RcFilename)
+
+		if v, err := readRcFile(rcFile, "version"); err != nil {
+			return nil, "", errors.Wrapf(err, "read rc file `%s` fail", rcFile)
+		} else if v != nil {
+			return v, rcFile, nil
+		}
+
+		parent := filepath.Dir(dir)
+
+		// reach the root
+		if parent == dir {
+			return nil, "", nil
+		}
+
+		dir = parent
+	}
+}
+
+// read the version spec from a pin file, returns nil if the file does not exist or is empty
+func readPinFile(file string) (*string, error) {
+	if exist, err := fs.PathExists(file); err != nil || !exist {
+		return nil, err
+	}
+
+	b, err := ioutil.ReadFile(file)
+
+	if err != nil {
+		return nil, err
+	}
+
+	v := strings.TrimSpace(string(b))
+
+	if v == "" {
+		return nil, nil
+	}
+
+	return &v, nil
+}
+
+// read the key from a rc file, returns nil if the file does not exist or the key is not set
+func readRcFile(file string, key string) (*string, error) {
+	values, err := config.ReadFile(file)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if v, ok := values[key]; ok {
+		return &v, nil
+	}
+
+	return nil, nil
+}
@@ -0,0 +1,97 @@
+package deno
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/axetroy/denox/internal/logger"
+	"github.com/axetroy/denox/internal/semver"
+	"github.com/pkg/errors"
+)
+
+const (
+	// `deno --version` should return immediately, but the first run may be slow on a busy CI machine
+	smokeTestTimeout = 30 * time.Second
+)
+
+var (
+	ErrSmokeTest = errors.New("smoke test of Deno fail")
+)
+
+// `deno 1.4.2 (release, x86_64-unknown-linux-gnu)` or `deno: 0.40.0` before v1
+var denoVersionRegexp = regexp.MustCompile(`(?m)^deno:?\s+v?(\S+)`)
+
+// run `deno --version` and make sure it is the version we want.
+// skip it with environment variable `DENOX_SKIP_SMOKE_TEST`
+func (d *Deno) smokeTest(executable string) error {
+	if os.Getenv("DENOX_SKIP_SMOKE_TEST") != "" {
+		logger.Debugf("skip smoke test of `%s`", executable)
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), smokeTestTimeout)
+
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, executable, "--version")
+
+	cmd.Env = append(os.Environ(), "NO_COLOR=1")
+
+	b, err := cmd.CombinedOutput()
+
+	output := strings.TrimSpace(string(b))
+
+	if ctx.Err() == context.DeadlineExceeded {
+		return errors.Wrapf(ErrSmokeTest, "`%s --version` does not exit in %s", executable, smokeTestTimeout)
+	}
+
+	if exitErr, ok := err.(*exec.ExitError); ok {
+		return errors.Wrapf(ErrSmokeTest, "`%s --version` exit with code %d%s:\n%s", executable, exitErr.ExitCode(), getLoaderHint(err, output), output)
+	} else if err != nil {
+		return errors.Wrapf(ErrSmokeTest, "run `%s --version` fail: %s%s", executable, err, getLoaderHint(err, output))
+	}
+
+	matches := denoVersionRegexp.FindStringSubmatch(output)
+
+	if len(matches) != 2 {
+		return errors.Wrapf(ErrSmokeTest, "unknown output of `%s --version`:\n%s", executable, output)
+	}
+
+	if !sameVersion(d.Version, matches[1]) {
+		return errors.Wrapf(ErrSmokeTest, "expected Deno %s but `%s --version` reports %s", d.Version, executable, matches[1])
+	}
+
+	logger.Debugf("smoke test of `%s` pass: %s", executable, strings.SplitN(output, "\n", 2)[0])
+
+	return nil
+}
+
+func sameVersion(a string, b string) bool {
+	va, err := semver.Parse(a)
+
+	if err != nil {
+		return strings.TrimPrefix(a, "v") == strings.TrimPrefix(b, "v")
+	}
+
+	vb, err := semver.Parse(b)
+
+	if err != nil {
+		return false
+	}
+
+	return va.Compare(vb) == 0
+}
+
+// explain the error of the dynamic loader, eg running a glibc binary on musl
+func getLoaderHint(err error, output string) string {
+	// the executable exists, so it is the interpreter in its ELF header not found
+	if os.IsNotExist(err) || strings.Contains(output, "error while loading shared libraries") || strings.Contains(output, "GLIBC_") {
+		return ", the dynamic loader or shared libraries are missing, the binary may not be built for your system (eg a glibc binary on musl)"
+	}
+
+	return ""
+}
@@ -0,0 +1,130 @@
+package deno
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/axetroy/denox/internal/testutil"
+	"github.com/pkg/errors"
+)
+
+func TestDenoVersionRegexp(t *testing.T) {
+	tests := []struct {
+		output   string
+		expected string
+	}{
+		{output: "deno 1.4.2 (release, x86_64-unknown-linux-gnu)\nv8 8.7.75\ntypescript 4.0.3", expected: "1.4.2"},
+		{output: "deno 2.0.0-rc.1 (release candidate, aarch64-apple-darwin)", expected: "2.0.0-rc.1"},
+		{output: "deno: 0.40.0\nv8: 8.2.308\ntypescript: 3.8.3", expected: "0.40.0"},
+		{output: "deno: v0.2.0", expected: "0.2.0"},
+		{output: "Download https://deno.land/x\ndeno 1.4.2 (release)", expected: "1.4.2"},
+		{output: "denox 1.4.2"},
+		{output: "Segmentation fault"},
+		{output: ""},
+	}
+
+	for _, test := range tests {
+		actual := ""
+
+		if matches := denoVersionRegexp.FindStringSubmatch(test.output); len(matches) == 2 {
+			actual = matches[1]
+		}
+
+		if actual != test.expected {
+			t.Errorf("expect `%s` for `%s`, got `%s`", test.expected, test.output, actual)
+		}
+	}
+}
+
+func TestSameVersion(t *testing.T) {
+	tests := []struct {
+		a      string
+		b      string
+		expect bool
+	}{
+		{a: "v1.4.2", b: "1.4.2", expect: true},
+		{a: "1.4.2", b: "v1.4.2", expect: true},
+		{a: "v1.4.2", b: "1.4.3"},
+		{a: "v2.0.0-rc.1", b: "2.0.0-rc.1", expect: true},
+		{a: "v2.0.0-rc.1", b: "2.0.0"},
+		{a: "v1.4.2", b: "unknown"},
+		{a: "canary", b: "canary", expect: true},
+		{a: "canary", b: "vcanary", expect: true},
+		{a: "canary", b: "1.4.2"},
+	}
+
+	for _, test := range tests {
+		if actual := sameVersion(test.a, test.b); actual != test.expect {
+			t.Errorf("expect sameVersion(`%s`, `%s`) to be %v, got %v", test.a, test.b, test.expect, actual)
+		}
+	}
+}
+
+func TestSmokeTest(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the fake Deno is a shell script")
+	}
+
+	dir, clean := testutil.TempDir(t)
+	defer clean()
+
+	defer testutil.Setenv(t, map[string]string{"DENOX_SKIP_SMOKE_TEST": ""})()
+
+	tests := []struct {
+		name    string
+		version string
+		script  string
+		err     bool
+		// the error message should contain it
+		message string
+	}{
+		{name: "pass", version: "v1.4.2", script: "echo 'deno 1.4.2 (release, x86_64-unknown-linux-gnu)'\necho 'v8 8.7.75'"},
+		{name: "pass before v1", version: "v0.40.0", script: "echo 'deno: 0.40.0'"},
+		{name: "version mismatch", version: "v1.4.2", script: "echo 'deno 1.4.3 (release, x86_64-unknown-linux-gnu)'", err: true, message: "reports 1.4.3"},
+		{name: "unknown output", version: "v1.4.2", script: "echo 'hello world'", err: true, message: "unknown output"},
+		{name: "exit with code", version: "v1.4.2", script: "echo 'deno 1.4.2'\nexit 3", err: true, message: "exit with code 3"},
+		{name: "missing shared libraries", version: "v1.4.2", script: "echo 'deno: error while loading shared libraries: libdl.so.2' >&2\nexit 127", err: true, message: "dynamic loader"},
+	}
+
+	for i, test := range tests {
+		executable := filepath.Join(dir, fmt.Sprintf("deno%d", i))
+
+		if err := ioutil.WriteFile(executable, []byte("#!/bin/sh\n"+test.script+"\n"), 0755); err != nil {
+			t.Fatal(err)
+		}
+
+		d := &Deno{Version: test.version}
+
+		err := d.smokeTest(executable)
+
+		if !test.err {
+			if err != nil {
+				t.Errorf("%s: %v", test.name, err)
+			}
+
+			continue
+		}
+
+		if errors.Cause(err) != ErrSmokeTest {
+			t.Errorf("%s: expect ErrSmokeTest, got %v", test.name, err)
+		} else if !strings.Contains(err.Error(), test.message) {
+			t.Errorf("%s: expect the error to contain `%s`, got %v", test.name, test.message, err)
+		}
+	}
+
+	d := &Deno{Version: "v1.4.2"}
+
+	if err := d.smokeTest(filepath.Join(dir, "not-exist")); errors.Cause(err) != ErrSmokeTest {
+		t.Errorf("expect ErrSmokeTest for the missing executable, got %v", err)
+	}
+
+	defer testutil.Setenv(t, map[string]string{"DENOX_SKIP_SMOKE_TEST": "1"})()
+
+	if err := d.smokeTest(filepath.Join(dir, "not-exist")); err != nil {
+		t.Errorf("expect the smoke test to be skipped, got %v", err)
+	}
+}
@@ -0,0 +1,283 @@
+package deno
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/axetroy/denox/internal/httpclient"
+	"github.com/axetroy/denox/internal/logger"
+	"github.com/pkg/errors"
+)
+
+const (
+	SetupDenoIndexURL = "https://denolib.github.io/setup-deno/release.json"
+	GitHubReleasesURL = "https://api.github.com/repos/denoland/deno/releases"
+
+	// the default sources of release index, can be overridden with environment variable `DENOX_RELEASE_SOURCES`
+	DefaultReleaseSources = "setup-deno,github"
+
+	// timeout of fetching the release index and checksum
+	httpGetTimeout = 10 * time.Second
+)
+
+// Asset is a downloadable file of a release
+type Asset struct {
+	Name string `json:"name"`
+	URL  string `json:"url,omitempty"`
+	Size int64  `json:"size,omitempty"`
+}
+
+// Release of Deno
+type Release struct {
+	Tag        string  `json:"tag"`
+	Prerelease bool    `json:"prerelease,omitempty"`
+	Assets     []Asset `json:"assets,omitempty"`
+}
+
+// ReleaseSource provides the list of Deno releases
+type ReleaseSource interface {
+	// name for the message
+	Name() string
+	// releases from the newest to the oldest
+	Releases() ([]Release, error)
+}
+
+// ParseReleaseSources parse a comma separated list of sources, the item can be
+// `setup-deno`, `github`, `github=<API URL>`, `file:///path/to/index.json` or `https://host/index.json`
+func ParseReleaseSources(s string) ([]ReleaseSource, error) {
+	sources := make([]ReleaseSource, 0)
+
+	for _, item := range strings.Split(s, ",") {
+		item = strings.TrimSpace(item)
+
+		switch {
+		case item == "":
+			continue
+		case item == "setup-deno":
+			sources = append(sources, &jsonSource{url: SetupDenoIndexURL})
+		case item == "github":
+			sources = append(sources, &githubSource{url: GitHubReleasesURL})
+		case strings.HasPrefix(item, "github="):
+			sources = append(sources, &githubSource{url: strings.TrimPrefix(item, "github=")})
+		case strings.HasPrefix(item, "file://"):
+			u, err := url.Parse(item)
+
+			if err != nil {
+				return nil, errors.Wrapf(err, "invalid release source `%s`", item)
+			}
+
+			sources = append(sources, &fileSource{path: filepath.FromSlash(u.Host + u.Path)})
+		case strings.HasPrefix(item, "http://"), strings.HasPrefix(item, "https://"):
+			sources = append(sources, &jsonSource{url: item})
+		default:
+			return nil, errors.Errorf("unknown release source `%s`", item)
+		}
+	}
+
+	if len(sources) == 0 {
+		return nil, errors.New("no release source")
+	}
+
+	return sources, nil
+}
+
+// get the release sources from environment variable `DENOX_RELEASE_SOURCES`
+func getReleaseSources() ([]ReleaseSource, error) {
+	s := os.Getenv("DENOX_RELEASE_SOURCES")
+
+	if s == "" {
+		s = DefaultReleaseSources
+	}
+
+	return ParseReleaseSources(s)
+}
+
+// fetch releases from the sources in order, returns the result of the first success one
+func fetchReleases(sources []ReleaseSource) ([]Release, error) {
+	messages := make([]string, 0)
+
+	for _, source := range sources {
+		releases, err := source.Releases()
+
+		if err == nil && len(releases) == 0 {
+			err = ErrVersionNotFound
+		}
+
+		if err != nil {
+			logger.Debugf("fetch releases from %s fail: %s", source.Name(), err)
+			messages = append(messages, fmt.Sprintf("%s: %s", source.Name(), err))
+			continue
+		}
+
+		logger.Debugf("fetch %d releases from %s", len(releases), source.Name())
+
+		return releases, nil
+	}
+
+	return nil, errors.New(strings.Join(messages, "; "))
+}
+
+// the JSON of release index, it is compatible with setup-deno and the GitHub Releases API
+type releaseJSON struct {
+	Name       string `json:"name"`
+	TagName    string `json:"tag_name"`
+	Tag        string `json:"tag"`
+	Prerelease bool   `json:"prerelease"`
+	Draft      bool   `json:"draft"`
+	Assets     []struct {
+		Name               string `json:"name"`
+		URL                string `json:"url"`
+		BrowserDownloadURL string `json:"browser_download_url"`
+		Size               int64  `json:"size"`
+	} `json:"assets"`
+}
+
+func parseReleaseJSON(b []byte) ([]Release, error) {
+	var items []releaseJSON
+
+	if err := json.Unmarshal(b, &items); err != nil {
+		return nil, errors.Wrap(err, "parse JSON fail")
+	}
+
+	releases := make([]Release, 0)
+
+	for _, item := range items {
+		if release, ok := item.release(); ok {
+			releases = append(releases, release)
+		}
+	}
+
+	return releases, nil
+}
+
+// convert to Release, it is false for the draft or the one without tag
+func (item *releaseJSON) release() (Release, bool) {
+	if item.Draft {
+		return Release{}, false
+	}
+
+	release := Release{Prerelease: item.Prerelease}
+
+	// GitHub API use `name` as the title and `tag_name` as the tag
+	switch {
+	case item.TagName != "":
+		release.Tag = item.TagName
+	case item.Tag != "":
+		release.Tag = item.Tag
+	default:
+		release.Tag = item.Name
+	}
+
+	if release.Tag == "" {
+		return Release{}, false
+	}
+
+	for _, asset := range item.Assets {
+		a := Asset{Name: asset.Name, URL: asset.BrowserDownloadURL, Size: asset.Size}
+
+		if a.URL == "" {
+			a.URL = asset.URL
+		}
+
+		release.Assets = append(release.Assets, a)
+	}
+
+	return release, true
+}
+
+// send the request and read the body, the caller should check the status code
+func httpDo(req *http.Request) (*http.Response, []byte, error) {
+	client, err := httpclient.Default()
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// do not wait too long for the small file, so that we can fallback to others
+	ctx, cancel := context.WithTimeout(context.Background(), httpGetTimeout)
+
+	defer cancel()
+
+	r, err := client.Do(req.WithContext(ctx))
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	defer r.Body.Close()
+
+	b, err := ioutil.ReadAll(r.Body)
+
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "read body fail")
+	}
+
+	return r, b, nil
+}
+
+// get the body of the URL
+func httpGet(u string) ([]byte, http.Header, error) {
+	req, err := http.NewRequest(http.MethodGet, u, nil)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	r, b, err := httpDo(req)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if r.StatusCode >= http.StatusBadRequest {
+		return nil, nil, errors.New(r.Status)
+	}
+
+	return b, r.Header, nil
+}
+
+// a JSON release index over HTTP, eg the index of setup-deno
+type jsonSource struct {
+	url string
+}
+
+func (s *jsonSource) Name() string {
+	return s.url
+}
+
+func (s *jsonSource) Releases() ([]Release, error) {
+	b, _, err := httpGet(s.url)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return parseReleaseJSON(b)
+}
+
+// a JSON release index on local disk
+type fileSource struct {
+	path string
+}
+
+func (s *fileSource) Name() string {
+	return "file://" + filepath.ToSlash(s.path)
+}
+
+func (s *fileSource) Releases() ([]Release, error) {
+	b, err := ioutil.ReadFile(s.path)
+
+	if err != nil {
+		return nil, errors.Wrapf(err, "read file `%s` fail", s.path)
+	}
+
+	return parseReleaseJSON(b)
+}
@@ -0,0 +1,123 @@
+package deno
+
+import (
+	"strings"
+	"time"
+
+	"github.com/axetroy/denox/internal/logger"
+	"github.com/axetroy/denox/internal/semver"
+	"github.com/pkg/errors"
+)
+
+var (
+	ErrVersionNotFound = errors.New("can not found version")
+)
+
+// ResolveVersion resolve a version spec into an exact release tag.
+// The spec can be an exact version (`v1.4.2`, `1.4.2`), a range (`^1.2`, `~1.4.0`, `1.x`, `>=1.3 <2`) or `latest`
+func ResolveVersion(spec string) (string, error) {
+	spec = strings.TrimSpace(spec)
+
+	// exact version, no need to look up the release index
+	if v, err := semver.Parse(spec); err == nil {
+		return v.String(), nil
+	}
+
+	if spec == "" {
+		spec = "latest"
+	}
+
+	r, err := semver.ParseRange(spec)
+
+	if err != nil {
+		return "", err
+	}
+
+	indexFile, err := getIndexFilepath()
+
+	if err != nil {
+		return "", err
+	}
+
+	index, err := loadIndex(indexFile)
+
+	if err != nil {
+		return "", errors.Wrap(err, "load release index fail")
+	}
+
+	if index != nil && index.fresh(getIndexTTL()) {
+		logger.Debugf("use cached release index `%s`", indexFile)
+		return matchVersion(r, index.Releases)
+	}
+
+	sources, err := getReleaseSources()
+
+	if err != nil {
+		return "", err
+	}
+
+	releases, fetchErr := fetchReleases(sources)
+
+	if fetchErr == nil {
+		if err := saveIndex(indexFile, releases); err != nil {
+			logger.Warnf("cache release index fail: %s", err)
+		}
+
+		return matchVersion(r, releases)
+	}
+
+	// network is unavailable, try the installed versions
+	installed, err := getInstalledVersions()
+
+	if err != nil {
+		return "", errors.Wrap(err, "get installed versions fail")
+	}
+
+	installedReleases := make([]Release, 0)
+
+	for _, tag := range installed {
+		installedReleases = append(installedReleases, Release{Tag: tag})
+	}
+
+	if tag, err := matchVersion(r, installedReleases); err == nil {
+		logger.Warnf("fetch Deno versions fail, use installed version `%s`: %s", tag, fetchErr)
+		return tag, nil
+	}
+
+	// then the stale cache
+	if index != nil {
+		logger.Warnf("fetch Deno versions fail, use release index cached at %s: %s", index.UpdatedAt.Format(time.RFC3339), fetchErr)
+		return matchVersion(r, index.Releases)
+	}
+
+	return "", errors.Wrap(fetchErr, "fetch Deno versions fail")
+}
+
+// find the newest tag that satisfies the range
+func matchVersion(r *semver.Range, releases []Release) (string, error) {
+	versions := make([]*semver.Version, 0)
+
+	for _, release := range releases {
+		// ignore the tag which is not semver
+		v, err := semver.Parse(release.Tag)
+
+		if err != nil {
+			continue
+		}
+
+		// the release marked as pre-release but without a pre-release tag, never pick it with a range
+		if release.Prerelease && v.Prerelease == "" {
+			continue
+		}
+
+		versions = append(versions, v)
+	}
+
+	latest := semver.Max(r.Filter(versions))
+
+	if latest == nil {
+		return "", errors.Wrapf(ErrVersionNotFound, "no version matches `%s`", r)
+	}
+
+	return latest.Original, nil
+}
@@ -0,0 +1,99 @@
+package dirs
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+
+	"github.com/pkg/errors"
+)
+
+// Layout is the dirs where denox stores its files
+type Layout struct {
+	// the installations of Deno, eg `~/.local/share/denox`
+	Data string
+	// the files which can be downloaded again, eg archives and the release index
+	Cache string
+	// the files which are not worth to back up, eg locks
+	State string
+	// the user config and the global default version
+	Config string
+}
+
+// Get the layout of denox dirs.
+// everything is under environment variable `DENOX_HOME` if it is set,
+// otherwise follow the XDG Base Directory Specification, or the conventions of macOS and Windows
+func Get() (*Layout, error) {
+	if home := os.Getenv("DENOX_HOME"); home != "" {
+		home, err := filepath.Abs(home)
+
+		if err != nil {
+			return nil, errors.Wrapf(err, "get absolute path of DENOX_HOME `%s` fail", home)
+		}
+
+		return &Layout{
+			Data:   home,
+			Cache:  filepath.Join(home, "cache"),
+			State:  filepath.Join(home, "state"),
+			Config: home,
+		}, nil
+	}
+
+	homeDir, err := os.UserHomeDir()
+
+	if err != nil {
+		return nil, errors.Wrap(err, "get user home dir fail")
+	}
+
+	switch runtime.GOOS {
+	case "windows":
+		localAppData := os.Getenv("LOCALAPPDATA")
+
+		if localAppData == "" {
+			localAppData = filepath.Join(homeDir, "AppData", "Local")
+		}
+
+		appData := os.Getenv("APPDATA")
+
+		if appData == "" {
+			appData = filepath.Join(homeDir, "AppData", "Roaming")
+		}
+
+		return &Layout{
+			Data:   filepath.Join(localAppData, "denox"),
+			Cache:  filepath.Join(localAppData, "denox", "cache"),
+			State:  filepath.Join(localAppData, "denox", "state"),
+			Config: filepath.Join(appData, "denox"),
+		}, nil
+	case "darwin":
+		support := filepath.Join(homeDir, "Library", "Application Support")
+		stateDir := filepath.Join(support, "denox", "state")
+
+		if dir := getXDGDir("XDG_STATE_HOME", ""); dir != "" {
+			stateDir = filepath.Join(dir, "denox")
+		}
+
+		return &Layout{
+			Data:   filepath.Join(getXDGDir("XDG_DATA_HOME", support), "denox"),
+			Cache:  filepath.Join(getXDGDir("XDG_CACHE_HOME", filepath.Join(homeDir, "Library", "Caches")), "denox"),
+			State:  stateDir,
+			Config: filepath.Join(getXDGDir("XDG_CONFIG_HOME", support), "denox"),
+		}, nil
+	default:
+		return &Layout{
+			Data:   filepath.Join(getXDGDir("XDG_DATA_HOME", filepath.Join(homeDir, ".local", "share")), "denox"),
+			Cache:  filepath.Join(getXDGDir("XDG_CACHE_HOME", filepath.Join(homeDir, ".cache")), "denox"),
+			State:  filepath.Join(getXDGDir("XDG_STATE_HOME", filepath.Join(homeDir, ".local", "state")), "denox"),
+			Config: filepath.Join(getXDGDir("XDG_CONFIG_HOME", filepath.Join(homeDir, ".config")), "denox"),
+		}, nil
+	}
+}
+
+// the spec says relative paths are invalid and should be ignored
+func getXDGDir(env string, defaultDir string) string {
+	if dir := os.Getenv(env); filepath.IsAbs(dir) {
+		return dir
+	}
+
+	return defaultDir
+}
@@ -0,0 +1,317 @@
+package download
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/axetroy/denox/internal/logger"
+	"github.com/axetroy/denox/internal/progress"
+	"github.com/pkg/errors"
+)
+
+// a range of the file, which is downloaded to its own file
+type chunk struct {
+	filepath string
+	start    int64
+	// inclusive
+	end int64
+}
+
+func (c *chunk) size() int64 {
+	return c.end - c.start + 1
+}
+
+// download the file in chunks concurrently, then join them into the part file.
+// returns false if the server does not support range requests, or the file is too small to split
+func (d *Downloader) downloadChunks(partFilepath string, url string) (bool, error) {
+	total, err := d.probe(url)
+
+	if err != nil {
+		logger.Debugf("download `%s` in a single connection: %s", url, err)
+		return false, removeStaleChunks(partFilepath, nil)
+	}
+
+	n := int64(d.Connections)
+
+	if d.MinChunkSize > 0 && total/d.MinChunkSize < n {
+		n = total / d.MinChunkSize
+	}
+
+	if n < 2 {
+		return false, removeStaleChunks(partFilepath, nil)
+	}
+
+	chunks := make([]*chunk, 0, n)
+	chunkSize := (total + n - 1) / n
+
+	for start := int64(0); start < total; start += chunkSize {
+		end := start + chunkSize - 1
+
+		if end >= total {
+			end = total - 1
+		}
+
+		chunks = append(chunks, &chunk{
+			filepath: fmt.Sprintf("%s.%d-%d", partFilepath, start, end),
+			start:    start,
+			end:      end,
+		})
+	}
+
+	if err := removeStaleChunks(partFilepath, chunks); err != nil {
+		return false, err
+	}
+
+	logger.Debugf("download `%s` in %d chunks", url, len(chunks))
+
+	// the chunks downloaded by the last run
+	var current int64
+
+	for _, c := range chunks {
+		if stat, err := os.Stat(c.filepath); err == nil && stat.Size() <= c.size() {
+			current += stat.Size()
+		}
+	}
+
+	bar := d.Progress.NewBar(strings.TrimSuffix(partFilepath, PartSuffix), total, current)
+
+	defer bar.Finish()
+
+	var (
+		wg   sync.WaitGroup
+		errs = make([]error, len(chunks))
+	)
+
+	for i, c := range chunks {
+		wg.Add(1)
+
+		go func(i int, c *chunk) {
+			defer wg.Done()
+
+			errs[i] = d.retry(url, func() error { return d.fetchChunk(c, url, bar) })
+		}(i, c)
+	}
+
+	wg.Wait()
+
+	// keep the downloaded chunks, so that they can be resumed
+	for _, err := range errs {
+		if err != nil {
+			return true, err
+		}
+	}
+
+	return true, joinChunks(partFilepath, chunks, total)
+}
+
+// get the size of the file if the server supports range requests
+func (d *Downloader) probe(url string) (int64, error) {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+
+	if err != nil {
+		return 0, err
+	}
+
+	req.Header.Set("Range", "bytes=0-0")
+
+	response, err := d.Client.Do(req)
+
+	if err != nil {
+		return 0, err
+	}
+
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusPartialContent {
+		return 0, errors.Errorf("range request is not supported, status code %d", response.StatusCode)
+	}
+
+	contentRange := response.Header.Get("Content-Range")
+
+	i := strings.LastIndex(contentRange, "/")
+
+	if i < 0 {
+		return 0, errors.Errorf("invalid Content-Range `%s`", contentRange)
+	}
+
+	total, err := strconv.ParseInt(contentRange[i+1:], 10, 64)
+
+	if err != nil {
+		return 0, errors.Errorf("unknown size from Content-Range `%s`", contentRange)
+	}
+
+	return total, nil
+}
+
+// fetch the rest of the chunk and append to the chunk file
+func (d *Downloader) fetchChunk(c *chunk, url string, bar progress.Bar) error {
+	var offset int64
+
+	if stat, err := os.Stat(c.filepath); err == nil {
+		offset = stat.Size()
+	} else if !os.IsNotExist(err) {
+		return errors.Wrapf(err, "stat file `%s` fail", c.filepath)
+	}
+
+	if offset == c.size() {
+		return nil
+	}
+
+	// the chunk file is broken, start over
+	if offset > c.size() {
+		if err := os.Remove(c.filepath); err != nil {
+			return errors.Wrapf(err, "remove file `%s` fail", c.filepath)
+		}
+
+		offset = 0
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	defer cancel()
+
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+
+	if err != nil {
+		return errors.Wrapf(err, "create request `%s` fail", url)
+	}
+
+	req = req.WithContext(ctx)
+
+	req.Header.Set("Range", fmt.Sprintf("bytes=%d-%d", c.start+offset, c.end))
+
+	response, err := d.Client.Do(req)
+
+	if err != nil {
+		return errors.Wrapf(err, "Download `%s` fail", url)
+	}
+
+	defer response.Body.Close()
+
+	switch {
+	case response.StatusCode == http.StatusPartialContent:
+		if start := parseContentRangeStart(response.Header.Get("Content-Range")); start != c.start+offset {
+			return errors.Errorf("unexpected Content-Range `%s`", response.Header.Get("Content-Range"))
+		}
+	case response.StatusCode >= http.StatusBadRequest:
+		return &StatusError{StatusCode: response.StatusCode, RetryAfter: parseRetryAfter(response.Header.Get("Retry-After"))}
+	default:
+		return errors.Errorf("range request is not supported, status code %d", response.StatusCode)
+	}
+
+	writer, err := os.OpenFile(c.filepath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+
+	if err != nil {
+		return errors.Wrapf(err, "Create `%s` fail", c.filepath)
+	}
+
+	defer writer.Close()
+
+	var reader io.Reader = newIdleTimeoutReader(response.Body, d.IdleTimeout, cancel)
+
+	reader = bar.NewProxyReader(reader)
+
+	expected := c.size() - offset
+
+	written, err := io.Copy(writer, io.LimitReader(reader, expected))
+
+	if err != nil {
+		return errors.Wrap(err, "copy fail")
+	}
+
+	if written != expected {
+		return errors.Wrapf(io.ErrUnexpectedEOF, "expected %d bytes but got %d", expected, written)
+	}
+
+	return nil
+}
+
+// remove the chunk files which do not belong to the chunks, eg the last run used a different number of connections
+func removeStaleChunks(partFilepath string, chunks []*chunk) error {
+	files, err := filepath.Glob(partFilepath + ".*-*")
+
+	if err != nil {
+		return err
+	}
+
+	for _, file := range files {
+		stale := true
+
+		for _, c := range chunks {
+			if c.filepath == file {
+				stale = false
+				break
+			}
+		}
+
+		if stale {
+			if err := os.Remove(file); err != nil {
+				return errors.Wrapf(err, "remove file `%s` fail", file)
+			}
+		}
+	}
+
+	return nil
+}
+
+// join the chunk files into the part file, and remove the chunk files
+func joinChunks(partFilepath string, chunks []*chunk, total int64) error {
+	writer, err := os.OpenFile(partFilepath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+
+	if err != nil {
+		return errors.Wrapf(err, "Create `%s` fail", partFilepath)
+	}
+
+	var written int64
+
+	for _, c := range chunks {
+		n, err := appendFile(writer, c.filepath)
+
+		if err != nil {
+			writer.Close()
+			return err
+		}
+
+		written += n
+	}
+
+	if err := writer.Close(); err != nil {
+		return errors.Wrapf(err, "close file `%s` fail", partFilepath)
+	}
+
+	if written != total {
+		_ = os.Remove(partFilepath)
+		return errors.Errorf("expected %d bytes but got %d", total, written)
+	}
+
+	for _, c := range chunks {
+		_ = os.Remove(c.filepath)
+	}
+
+	return nil
+}
+
+func appendFile(writer io.Writer, file string) (int64, error) {
+	reader, err := os.Open(file)
+
+	if err != nil {
+		return 0, errors.Wrapf(err, "open file `%s` fail", file)
+	}
+
+	defer reader.Close()
+
+	n, err := io.Copy(writer, reader)
+
+	if err != nil {
+		return n, errors.Wrapf(err, "copy file `%s` fail", file)
+	}
+
+	return n, nil
+}
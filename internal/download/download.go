@@ -0,0 +1,355 @@
+package download
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math/rand"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/axetroy/denox/internal/logger"
+	"github.com/axetroy/denox/internal/progress"
+	"github.com/pkg/errors"
+)
+
+const (
+	DefaultRetries     = 5
+	DefaultMinBackoff  = time.Second
+	DefaultMaxBackoff  = 30 * time.Second
+	DefaultIdleTimeout = time.Minute
+	DefaultConnections = 4
+	// do not split the file into chunks smaller than this
+	DefaultMinChunkSize = 4 << 20
+
+	// the suffix of the file which is downloading
+	PartSuffix = ".part"
+)
+
+// StatusError is the error of unexpected HTTP status code
+type StatusError struct {
+	StatusCode int
+	// the duration the server asks to wait, from the header `Retry-After`
+	RetryAfter time.Duration
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("download file with status code %d", e.StatusCode)
+}
+
+// Downloader downloads file with retry and resume
+type Downloader struct {
+	Client *http.Client
+	// max retries of transient errors
+	Retries int
+	// the backoff grows exponentially from MinBackoff to MaxBackoff
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+	// abort the connection if no data received in the duration
+	IdleTimeout time.Duration
+	// max concurrent connections of a download, 1 to disable chunked download
+	Connections int
+	// the min size of a chunk
+	MinChunkSize int64
+	// report the progress, nil to disable it
+	Progress *progress.Reporter
+	// it can be replaced in test
+	sleep func(time.Duration)
+}
+
+// New create a Downloader with default options
+func New(client *http.Client) *Downloader {
+	return &Downloader{
+		Client:       client,
+		Retries:      DefaultRetries,
+		MinBackoff:   DefaultMinBackoff,
+		MaxBackoff:   DefaultMaxBackoff,
+		IdleTimeout:  DefaultIdleTimeout,
+		Connections:  DefaultConnections,
+		MinChunkSize: DefaultMinChunkSize,
+		Progress:     progress.Default(),
+	}
+}
+
+// Download file from URL to the filepath.
+// the data is written to `<filepath>.part` first, and it resumes from the part file if it exists.
+// if the server supports range requests, the file is split into chunks and downloaded concurrently
+func (d *Downloader) Download(filepath string, url string) error {
+	partFilepath := filepath + PartSuffix
+
+	d.Progress.Event("download_start", progress.Fields{"url": url, "file": filepath})
+
+	startedAt := time.Now()
+
+	chunked := false
+
+	// a part file means a single stream download was interrupted, resume it instead
+	if _, err := os.Stat(partFilepath); os.IsNotExist(err) && d.Connections > 1 {
+		var err error
+
+		if chunked, err = d.downloadChunks(partFilepath, url); err != nil {
+			return err
+		}
+	}
+
+	if !chunked {
+		// one bar for all the attempts, the total is known after the response
+		bar := d.Progress.NewBar(filepath, -1, 0)
+
+		err := d.retry(url, func() error { return d.fetch(partFilepath, url, bar) })
+
+		bar.Finish()
+
+		if err != nil {
+			return err
+		}
+	}
+
+	if err := os.Rename(partFilepath, filepath); err != nil {
+		return errors.Wrapf(err, "rename `%s` fail", partFilepath)
+	}
+
+	fields := progress.Fields{"url": url, "file": filepath, "chunked": chunked, "duration_ms": time.Since(startedAt).Milliseconds()}
+
+	if stat, err := os.Stat(filepath); err == nil {
+		fields["size"] = stat.Size()
+	}
+
+	d.Progress.Event("download_finish", fields)
+
+	return nil
+}
+
+// call the function until it success, retry the transient errors with backoff
+func (d *Downloader) retry(url string, fn func() error) error {
+	var lastErr error
+
+	for attempt := 0; attempt <= d.Retries; attempt++ {
+		if attempt > 0 {
+			wait := d.backoff(attempt, lastErr)
+			logger.Debugf("retry download `%s` in %s: %s", url, wait, lastErr)
+			d.doSleep(wait)
+		}
+
+		err := fn()
+
+		if err == nil {
+			return nil
+		}
+
+		if !IsRetryable(err) {
+			return err
+		}
+
+		lastErr = err
+	}
+
+	return errors.Wrapf(lastErr, "download `%s` fail after %d retries", url, d.Retries)
+}
+
+// the backoff before the attempt, with full jitter.
+// the `Retry-After` of server is respected, but it never exceeds MaxBackoff, so that a server can not stall us for hours
+func (d *Downloader) backoff(attempt int, err error) time.Duration {
+	if statusErr, ok := errors.Cause(err).(*StatusError); ok && statusErr.RetryAfter > 0 {
+		if d.MaxBackoff > 0 && statusErr.RetryAfter > d.MaxBackoff {
+			return d.MaxBackoff
+		}
+
+		return statusErr.RetryAfter
+	}
+
+	max := d.MinBackoff << uint(attempt-1)
+
+	if max > d.MaxBackoff || max <= 0 {
+		max = d.MaxBackoff
+	}
+
+	if max <= 0 {
+		return 0
+	}
+
+	return max/2 + time.Duration(rand.Int63n(int64(max/2)+1))
+}
+
+func (d *Downloader) doSleep(duration time.Duration) {
+	if d.sleep != nil {
+		d.sleep(duration)
+	} else {
+		time.Sleep(duration)
+	}
+}
+
+// fetch the URL and append to the part file
+func (d *Downloader) fetch(partFilepath string, url string, bar progress.Bar) error {
+	var offset int64
+
+	if stat, err := os.Stat(partFilepath); err == nil {
+		offset = stat.Size()
+	} else if !os.IsNotExist(err) {
+		return errors.Wrapf(err, "stat file `%s` fail", partFilepath)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	defer cancel()
+
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+
+	if err != nil {
+		return errors.Wrapf(err, "create request `%s` fail", url)
+	}
+
+	req = req.WithContext(ctx)
+
+	if offset > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+	}
+
+	response, err := d.Client.Do(req)
+
+	if err != nil {
+		return errors.Wrapf(err, "Download `%s` fail", url)
+	}
+
+	defer response.Body.Close()
+
+	switch {
+	case response.StatusCode == http.StatusPartialContent:
+		if start := parseContentRangeStart(response.Header.Get("Content-Range")); start != offset {
+			// the server does not respond what we ask for, start over
+			_ = os.Remove(partFilepath)
+			bar.SetCurrent(0)
+			return errors.Wrapf(io.ErrUnexpectedEOF, "unexpected Content-Range `%s`", response.Header.Get("Content-Range"))
+		}
+	case response.StatusCode == http.StatusRequestedRangeNotSatisfiable:
+		// the part file is broken, start over
+		_ = os.Remove(partFilepath)
+		bar.SetCurrent(0)
+		return &StatusError{StatusCode: response.StatusCode}
+	case response.StatusCode >= http.StatusBadRequest:
+		return &StatusError{StatusCode: response.StatusCode, RetryAfter: parseRetryAfter(response.Header.Get("Retry-After"))}
+	default:
+		// the server does not support range request
+		offset = 0
+	}
+
+	flag := os.O_CREATE | os.O_WRONLY | os.O_APPEND
+
+	if offset == 0 {
+		flag |= os.O_TRUNC
+	} else {
+		logger.Debugf("resume download `%s` from %d bytes", url, offset)
+	}
+
+	writer, err := os.OpenFile(partFilepath, flag, 0644)
+
+	if err != nil {
+		return errors.Wrapf(err, "Create `%s` fail", partFilepath)
+	}
+
+	defer writer.Close()
+
+	var reader io.Reader = newIdleTimeoutReader(response.Body, d.IdleTimeout, cancel)
+
+	total := int64(-1)
+
+	if response.ContentLength >= 0 {
+		total = offset + response.ContentLength
+	}
+
+	if total >= 0 {
+		bar.SetTotal(total)
+	}
+
+	// the part file is truncated if the server does not support range requests
+	bar.SetCurrent(offset)
+
+	reader = bar.NewProxyReader(reader)
+
+	written, err := io.Copy(writer, reader)
+
+	if err != nil {
+		return errors.Wrap(err, "copy fail")
+	}
+
+	if response.ContentLength >= 0 && written != response.ContentLength {
+		return errors.Wrapf(io.ErrUnexpectedEOF, "expected %d bytes but got %d", response.ContentLength, written)
+	}
+
+	return nil
+}
+
+// IsRetryable reports whether the error is transient
+func IsRetryable(err error) bool {
+	cause := errors.Cause(err)
+
+	if statusErr, ok := cause.(*StatusError); ok {
+		return statusErr.StatusCode >= http.StatusInternalServerError ||
+			statusErr.StatusCode == http.StatusTooManyRequests ||
+			statusErr.StatusCode == http.StatusRequestedRangeNotSatisfiable
+	}
+
+	// the error of http.Client is *url.Error, eg `Get "...": EOF` when the server closes the connection
+	if urlErr, ok := cause.(*url.Error); ok {
+		cause = errors.Cause(urlErr.Err)
+	}
+
+	if cause == io.EOF || cause == io.ErrUnexpectedEOF || cause == context.Canceled {
+		return true
+	}
+
+	// the certificate will not become valid by retrying
+	if msg := cause.Error(); strings.Contains(msg, "x509:") || strings.Contains(msg, "tls:") {
+		return false
+	}
+
+	if _, ok := cause.(net.Error); ok {
+		return true
+	}
+
+	// the error of connection reset is not always a net.Error
+	msg := cause.Error()
+
+	return strings.Contains(msg, "connection reset") || strings.Contains(msg, "broken pipe")
+}
+
+// parse the start of `Content-Range: bytes 100-199/200`
+func parseContentRangeStart(s string) int64 {
+	s = strings.TrimPrefix(strings.TrimSpace(s), "bytes ")
+
+	i := strings.Index(s, "-")
+
+	if i < 0 {
+		return -1
+	}
+
+	start, err := strconv.ParseInt(s[:i], 10, 64)
+
+	if err != nil {
+		return -1
+	}
+
+	return start
+}
+
+// parse `Retry-After` in seconds or HTTP date
+func parseRetryAfter(s string) time.Duration {
+	if s == "" {
+		return 0
+	}
+
+	if seconds, err := strconv.Atoi(s); err == nil {
+		return time.Duration(seconds) * time.Second
+	}
+
+	if t, err := http.ParseTime(s); err == nil {
+		return time.Until(t)
+	}
+
+	return 0
+}
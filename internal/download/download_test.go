@@ -0,0 +1,543 @@
+package download
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/axetroy/denox/internal/progress"
+	"github.com/axetroy/denox/internal/testutil"
+	"github.com/pkg/errors"
+)
+
+// the requests received by the test server
+type requests struct {
+	mu     sync.Mutex
+	ranges []string
+}
+
+func (r *requests) add(req *http.Request) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.ranges = append(r.ranges, req.Header.Get("Range"))
+
+	return len(r.ranges)
+}
+
+func (r *requests) get() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return append([]string(nil), r.ranges...)
+}
+
+func newData(size int) []byte {
+	data := make([]byte, size)
+
+	rand.New(rand.NewSource(int64(size))).Read(data)
+
+	return data
+}
+
+// serve the data with the support of range requests
+func serveData(w http.ResponseWriter, r *http.Request, data []byte) {
+	http.ServeContent(w, r, "deno.zip", time.Time{}, bytes.NewReader(data))
+}
+
+// write a part of the data with the full Content-Length, then drop the connection
+func dropConnection(t *testing.T, w http.ResponseWriter, data []byte, n int) {
+	w.Header().Set("Content-Length", fmt.Sprintf("%d", len(data)))
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(data[:n])
+	w.(http.Flusher).Flush()
+
+	conn, _, err := w.(http.Hijacker).Hijack()
+
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	_ = conn.Close()
+}
+
+// create a Downloader which records the backoff instead of sleeping
+func newTestDownloader(server *httptest.Server) (*Downloader, *[]time.Duration) {
+	sleeps := make([]time.Duration, 0)
+
+	d := New(server.Client())
+	d.Progress = nil
+	d.Connections = 1
+	d.sleep = func(duration time.Duration) {
+		sleeps = append(sleeps, duration)
+	}
+
+	return d, &sleeps
+}
+
+func assertFile(t *testing.T, file string, expected []byte) {
+	actual, err := ioutil.ReadFile(file)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(actual, expected) {
+		t.Fatalf("expect %d bytes of the data, got %d bytes which are different", len(expected), len(actual))
+	}
+
+	if _, err := os.Stat(file + PartSuffix); !os.IsNotExist(err) {
+		t.Fatalf("expect the part file is removed, got %v", err)
+	}
+}
+
+func TestDownloadResume(t *testing.T) {
+	data := newData(64 << 10)
+	reqs := &requests{}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if reqs.add(r) == 1 {
+			dropConnection(t, w, data, 1000)
+			return
+		}
+
+		serveData(w, r, data)
+	}))
+
+	defer server.Close()
+
+	dir, clean := testutil.TempDir(t)
+	defer clean()
+
+	d, sleeps := newTestDownloader(server)
+	file := filepath.Join(dir, "deno.zip")
+
+	if err := d.Download(file, server.URL); err != nil {
+		t.Fatal(err)
+	}
+
+	assertFile(t, file, data)
+
+	if expected := []string{"", "bytes=1000-"}; fmt.Sprint(reqs.get()) != fmt.Sprint(expected) {
+		t.Fatalf("expect requests with range %q, got %q", expected, reqs.get())
+	}
+
+	if len(*sleeps) != 1 {
+		t.Fatalf("expect 1 backoff, got %v", *sleeps)
+	}
+}
+
+func TestDownloadRangeNotSatisfiable(t *testing.T) {
+	data := newData(1 << 10)
+	reqs := &requests{}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		reqs.add(r)
+		serveData(w, r, data)
+	}))
+
+	defer server.Close()
+
+	dir, clean := testutil.TempDir(t)
+	defer clean()
+
+	d, sleeps := newTestDownloader(server)
+	file := filepath.Join(dir, "deno.zip")
+
+	// the part file is longer than the file on server, eg the file is changed
+	if err := ioutil.WriteFile(file+PartSuffix, newData(2<<10), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := d.Download(file, server.URL); err != nil {
+		t.Fatal(err)
+	}
+
+	assertFile(t, file, data)
+
+	if expected := []string{"bytes=2048-", ""}; fmt.Sprint(reqs.get()) != fmt.Sprint(expected) {
+		t.Fatalf("expect requests with range %q, got %q", expected, reqs.get())
+	}
+
+	if len(*sleeps) != 1 {
+		t.Fatalf("expect 1 backoff, got %v", *sleeps)
+	}
+}
+
+func TestDownloadProgress(t *testing.T) {
+	data := newData(64 << 10)
+	reqs := &requests{}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch reqs.add(r) {
+		case 1:
+			dropConnection(t, w, data, 1000)
+		case 2:
+			// not the range we ask for, the part file is dropped
+			w.Header().Set("Content-Range", fmt.Sprintf("bytes 0-%d/%d", len(data)-1, len(data)))
+			w.WriteHeader(http.StatusPartialContent)
+			_, _ = w.Write(data)
+		default:
+			serveData(w, r, data)
+		}
+	}))
+
+	defer server.Close()
+
+	dir, clean := testutil.TempDir(t)
+	defer clean()
+
+	var output bytes.Buffer
+
+	d, _ := newTestDownloader(server)
+	d.Progress = progress.New(progress.ModeJSON, &output)
+	file := filepath.Join(dir, "deno.zip")
+
+	if err := d.Download(file, server.URL); err != nil {
+		t.Fatal(err)
+	}
+
+	assertFile(t, file, data)
+
+	if expected := []string{"", "bytes=1000-", ""}; fmt.Sprint(reqs.get()) != fmt.Sprint(expected) {
+		t.Fatalf("expect requests with range %q, got %q", expected, reqs.get())
+	}
+
+	// one bar for all the attempts, it is rewound when the part file is dropped
+	events := make([]map[string]interface{}, 0)
+
+	for _, line := range strings.Split(strings.TrimSpace(output.String()), "\n") {
+		var event map[string]interface{}
+
+		if err := json.Unmarshal([]byte(line), &event); err != nil {
+			t.Fatal(err)
+		}
+
+		if event["event"] == "download_progress" {
+			events = append(events, event)
+		}
+	}
+
+	if len(events) != 1 {
+		t.Fatalf("expect 1 progress event, got %v", events)
+	}
+
+	if current, total := events[0]["current"], events[0]["total"]; current != float64(len(data)) || total != float64(len(data)) {
+		t.Fatalf("expect the progress %d/%d, got %v/%v", len(data), len(data), current, total)
+	}
+}
+
+func TestDownloadRetry(t *testing.T) {
+	tests := []struct {
+		name string
+		// the responses before the data is served
+		statuses   []int
+		retryAfter string
+		retries    int
+		err        bool
+		// the backoff expected, 0 for any value in [MinBackoff, MaxBackoff]
+		sleeps []time.Duration
+	}{
+		{
+			name:     "5xx",
+			statuses: []int{http.StatusInternalServerError, http.StatusBadGateway, http.StatusServiceUnavailable},
+			retries:  5,
+			sleeps:   []time.Duration{0, 0, 0},
+		},
+		{
+			name:       "429 with Retry-After",
+			statuses:   []int{http.StatusTooManyRequests},
+			retryAfter: "7",
+			retries:    5,
+			sleeps:     []time.Duration{7 * time.Second},
+		},
+		{
+			name:       "Retry-After is clamped to MaxBackoff",
+			statuses:   []int{http.StatusTooManyRequests},
+			retryAfter: "86400",
+			retries:    5,
+			sleeps:     []time.Duration{DefaultMaxBackoff},
+		},
+		{
+			name:     "too many retries",
+			statuses: []int{http.StatusBadGateway, http.StatusBadGateway, http.StatusBadGateway},
+			retries:  2,
+			err:      true,
+			sleeps:   []time.Duration{0, 0},
+		},
+		{
+			name:     "4xx is not retried",
+			statuses: []int{http.StatusNotFound},
+			retries:  5,
+			err:      true,
+			sleeps:   []time.Duration{},
+		},
+	}
+
+	data := newData(1 << 10)
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			reqs := &requests{}
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if n := reqs.add(r); n <= len(test.statuses) {
+					if test.retryAfter != "" {
+						w.Header().Set("Retry-After", test.retryAfter)
+					}
+
+					w.WriteHeader(test.statuses[n-1])
+
+					return
+				}
+
+				serveData(w, r, data)
+			}))
+
+			defer server.Close()
+
+			dir, clean := testutil.TempDir(t)
+			defer clean()
+
+			d, sleeps := newTestDownloader(server)
+			d.Retries = test.retries
+			file := filepath.Join(dir, "deno.zip")
+
+			err := d.Download(file, server.URL)
+
+			if test.err && err == nil {
+				t.Fatal("expect error, got nil")
+			} else if !test.err && err != nil {
+				t.Fatal(err)
+			}
+
+			if !test.err {
+				assertFile(t, file, data)
+			}
+
+			if len(*sleeps) != len(test.sleeps) {
+				t.Fatalf("expect %d backoff, got %v", len(test.sleeps), *sleeps)
+			}
+
+			for i, expected := range test.sleeps {
+				actual := (*sleeps)[i]
+
+				if expected == 0 && (actual < d.MinBackoff/2 || actual > d.MaxBackoff) {
+					t.Fatalf("expect backoff in [%s, %s], got %s", d.MinBackoff/2, d.MaxBackoff, actual)
+				} else if expected != 0 && actual != expected {
+					t.Fatalf("expect backoff %s, got %s", expected, actual)
+				}
+			}
+		})
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	d := &Downloader{MinBackoff: time.Second, MaxBackoff: 10 * time.Second}
+
+	for attempt := 1; attempt <= 10; attempt++ {
+		max := time.Second << uint(attempt-1)
+
+		if max > d.MaxBackoff {
+			max = d.MaxBackoff
+		}
+
+		if actual := d.backoff(attempt, errors.New("EOF")); actual < max/2 || actual > max {
+			t.Fatalf("expect backoff of attempt %d in [%s, %s], got %s", attempt, max/2, max, actual)
+		}
+	}
+}
+
+func TestDownloadIdleTimeout(t *testing.T) {
+	data := newData(64 << 10)
+	reqs := &requests{}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if reqs.add(r) > 1 {
+			serveData(w, r, data)
+			return
+		}
+
+		// send a part of the data and stall
+		w.Header().Set("Content-Length", fmt.Sprintf("%d", len(data)))
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write(data[:1000])
+		w.(http.Flusher).Flush()
+
+		select {
+		case <-r.Context().Done():
+		case <-time.After(10 * time.Second):
+		}
+	}))
+
+	defer server.Close()
+
+	dir, clean := testutil.TempDir(t)
+	defer clean()
+
+	d, sleeps := newTestDownloader(server)
+	d.IdleTimeout = 100 * time.Millisecond
+	file := filepath.Join(dir, "deno.zip")
+
+	startedAt := time.Now()
+
+	if err := d.Download(file, server.URL); err != nil {
+		t.Fatal(err)
+	}
+
+	if elapsed := time.Since(startedAt); elapsed > 5*time.Second {
+		t.Fatalf("expect the stalled connection is aborted by the idle timeout, it takes %s", elapsed)
+	}
+
+	assertFile(t, file, data)
+
+	if expected := []string{"", "bytes=1000-"}; fmt.Sprint(reqs.get()) != fmt.Sprint(expected) {
+		t.Fatalf("expect requests with range %q, got %q", expected, reqs.get())
+	}
+
+	if len(*sleeps) != 1 {
+		t.Fatalf("expect 1 backoff, got %v", *sleeps)
+	}
+}
+
+func TestDownloadChunks(t *testing.T) {
+	data := newData(100)
+	reqs := &requests{}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		reqs.add(r)
+		serveData(w, r, data)
+	}))
+
+	defer server.Close()
+
+	dir, clean := testutil.TempDir(t)
+	defer clean()
+
+	d, _ := newTestDownloader(server)
+	d.Connections = 4
+	d.MinChunkSize = 10
+	file := filepath.Join(dir, "deno.zip")
+
+	// the first chunk downloaded partly by the last run, and a stale chunk of a different split
+	if err := ioutil.WriteFile(file+PartSuffix+".0-24", data[:10], 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := ioutil.WriteFile(file+PartSuffix+".0-49", data[:50], 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := d.Download(file, server.URL); err != nil {
+		t.Fatal(err)
+	}
+
+	assertFile(t, file, data)
+
+	ranges := reqs.get()
+
+	if len(ranges) != 5 {
+		t.Fatalf("expect a probe and 4 chunk requests, got %q", ranges)
+	}
+
+	for _, expected := range []string{"bytes=0-0", "bytes=10-24", "bytes=25-49", "bytes=50-74", "bytes=75-99"} {
+		if !strings.Contains(fmt.Sprint(ranges), expected) {
+			t.Fatalf("expect request with range %s, got %q", expected, ranges)
+		}
+	}
+
+	if chunks, _ := filepath.Glob(file + PartSuffix + ".*"); len(chunks) != 0 {
+		t.Fatalf("expect the chunk files are removed, got %v", chunks)
+	}
+}
+
+func TestDownloadChunksUnsupported(t *testing.T) {
+	data := newData(100)
+	reqs := &requests{}
+
+	// the server ignores Range
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		reqs.add(r)
+		_, _ = w.Write(data)
+	}))
+
+	defer server.Close()
+
+	dir, clean := testutil.TempDir(t)
+	defer clean()
+
+	d, _ := newTestDownloader(server)
+	d.Connections = 4
+	d.MinChunkSize = 10
+	file := filepath.Join(dir, "deno.zip")
+
+	if err := d.Download(file, server.URL); err != nil {
+		t.Fatal(err)
+	}
+
+	assertFile(t, file, data)
+
+	if expected := []string{"bytes=0-0", ""}; fmt.Sprint(reqs.get()) != fmt.Sprint(expected) {
+		t.Fatalf("expect a probe and a single request, got %q", reqs.get())
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected time.Duration
+	}{
+		{"", 0},
+		{"120", 2 * time.Minute},
+		{"invalid", 0},
+	}
+
+	for _, test := range tests {
+		if actual := parseRetryAfter(test.input); actual != test.expected {
+			t.Fatalf("expect %s for `%s`, got %s", test.expected, test.input, actual)
+		}
+	}
+
+	if actual := parseRetryAfter(time.Now().Add(time.Hour).UTC().Format(http.TimeFormat)); actual < 59*time.Minute || actual > time.Hour {
+		t.Fatalf("expect about 1h for HTTP date, got %s", actual)
+	}
+}
+
+func TestIsRetryable(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		expected bool
+	}{
+		{"5xx", &StatusError{StatusCode: http.StatusBadGateway}, true},
+		{"429", errors.Wrap(&StatusError{StatusCode: http.StatusTooManyRequests}, "download fail"), true},
+		{"404", &StatusError{StatusCode: http.StatusNotFound}, false},
+		{"EOF", errors.Wrap(io.EOF, "copy fail"), true},
+		{"unexpected EOF", errors.Wrap(io.ErrUnexpectedEOF, "copy fail"), true},
+		{"EOF of request", errors.Wrap(&url.Error{Op: "Get", URL: "https://example.com", Err: io.EOF}, "download fail"), true},
+		{"canceled by idle timeout", errors.Wrap(context.Canceled, "copy fail"), true},
+		{"connection reset", errors.New("read tcp: connection reset by peer"), true},
+		{"not EOF", errors.New("invalid EOF marker in the archive"), false},
+		{"certificate", &url.Error{Op: "Get", URL: "https://example.com", Err: errors.New("x509: certificate signed by unknown authority")}, false},
+	}
+
+	for _, test := range tests {
+		if actual := IsRetryable(test.err); actual != test.expected {
+			t.Errorf("expect IsRetryable to be %v for %s, got %v", test.expected, test.name, actual)
+		}
+	}
+}
@@ -0,0 +1,40 @@
+package download
+
+import (
+	"context"
+	"io"
+	"time"
+)
+
+// idleTimeoutReader cancels the request if no data is read in the timeout
+type idleTimeoutReader struct {
+	reader  io.Reader
+	timeout time.Duration
+	timer   *time.Timer
+}
+
+func newIdleTimeoutReader(reader io.Reader, timeout time.Duration, cancel context.CancelFunc) io.Reader {
+	if timeout <= 0 {
+		return reader
+	}
+
+	return &idleTimeoutReader{
+		reader:  reader,
+		timeout: timeout,
+		timer:   time.AfterFunc(timeout, cancel),
+	}
+}
+
+func (r *idleTimeoutReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+
+	if n > 0 {
+		r.timer.Reset(r.timeout)
+	}
+
+	if err != nil {
+		r.timer.Stop()
+	}
+
+	return n, err
+}
@@ -0,0 +1,73 @@
+package download
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/axetroy/denox/internal/progress"
+	"github.com/pkg/errors"
+)
+
+// Stream the body of URL to fn without writing to disk, fn must read the body to the end.
+// it is not retried, because the data consumed by fn can not be taken back
+func (d *Downloader) Stream(url string, name string, fn func(body io.Reader) error) error {
+	d.Progress.Event("download_start", progress.Fields{"url": url, "stream": true})
+
+	startedAt := time.Now()
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	defer cancel()
+
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+
+	if err != nil {
+		return errors.Wrapf(err, "create request `%s` fail", url)
+	}
+
+	response, err := d.Client.Do(req.WithContext(ctx))
+
+	if err != nil {
+		return errors.Wrapf(err, "Download `%s` fail", url)
+	}
+
+	defer response.Body.Close()
+
+	if response.StatusCode >= http.StatusBadRequest {
+		return &StatusError{StatusCode: response.StatusCode, RetryAfter: parseRetryAfter(response.Header.Get("Retry-After"))}
+	}
+
+	bar := d.Progress.NewBar(name, response.ContentLength, 0)
+
+	defer bar.Finish()
+
+	body := &countingReader{reader: bar.NewProxyReader(newIdleTimeoutReader(response.Body, d.IdleTimeout, cancel))}
+
+	if err := fn(body); err != nil {
+		return err
+	}
+
+	if response.ContentLength >= 0 && body.n != response.ContentLength {
+		return errors.Errorf("expected %d bytes but got %d", response.ContentLength, body.n)
+	}
+
+	d.Progress.Event("download_finish", progress.Fields{"url": url, "stream": true, "size": body.n, "duration_ms": time.Since(startedAt).Milliseconds()})
+
+	return nil
+}
+
+// countingReader counts the bytes read
+type countingReader struct {
+	reader io.Reader
+	n      int64
+}
+
+func (b *countingReader) Read(p []byte) (int, error) {
+	n, err := b.reader.Read(p)
+
+	b.n += int64(n)
+
+	return n, err
+}
@@ -0,0 +1,36 @@
+package fs
+
+import (
+	"path/filepath"
+
+	"github.com/pkg/errors"
+)
+
+// FindUp walk up from dir and returns the path of the first file named `name`.
+// returns empty string if not found
+func FindUp(dir string, name string) (string, error) {
+	dir, err := filepath.Abs(dir)
+
+	if err != nil {
+		return "", errors.Wrapf(err, "get absolute path of `%s` fail", dir)
+	}
+
+	for {
+		file := filepath.Join(dir, name)
+
+		if exist, err := PathExists(file); err != nil {
+			return "", err
+		} else if exist {
+			return file, nil
+		}
+
+		parent := filepath.Dir(dir)
+
+		// reach the root
+		if parent == dir {
+			return "", nil
+		}
+
+		dir = parent
+	}
+}
@@ -0,0 +1,44 @@
+package fs
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+)
+
+// WriteFileAtomic write to a temp file then rename, so that other process never read a partial file
+func WriteFileAtomic(file string, b []byte) error {
+	// a unique temporary file in the same dir, so the concurrent writers do not clobber each other
+	f, err := ioutil.TempFile(filepath.Dir(file), filepath.Base(file)+".*.tmp")
+
+	if err != nil {
+		return errors.Wrap(err, "create temporary file fail")
+	}
+
+	tmpFile := f.Name()
+
+	_, err = f.Write(b)
+
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+
+	if err == nil {
+		// ioutil.TempFile creates the file with 0600
+		err = os.Chmod(tmpFile, 0644)
+	}
+
+	if err != nil {
+		_ = os.Remove(tmpFile)
+		return errors.Wrapf(err, "write file `%s` fail", tmpFile)
+	}
+
+	if err := os.Rename(tmpFile, file); err != nil {
+		_ = os.Remove(tmpFile)
+		return errors.Wrapf(err, "rename file `%s` fail", tmpFile)
+	}
+
+	return nil
+}
@@ -0,0 +1,258 @@
+package httpclient
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/axetroy/denox/internal/config"
+	"github.com/axetroy/denox/internal/logger"
+	"github.com/pkg/errors"
+)
+
+const (
+	DefaultConnectTimeout = 30 * time.Second
+)
+
+// Options of the HTTP client
+type Options struct {
+	// timeout of connecting, TLS handshake and waiting for the response header
+	ConnectTimeout time.Duration
+	// the proxy URL, use `HTTPS_PROXY`, `HTTP_PROXY` and `NO_PROXY` if it is empty
+	Proxy string
+	// the extra CA bundle files in PEM format, they are appended to the system roots
+	CAFiles []string
+	// the client certificate and its private key in PEM format
+	ClientCert string
+	ClientKey  string
+	// skip the verification of server certificate, DANGEROUS
+	Insecure bool
+}
+
+// OptionsFromEnv read the options from config `proxy` and environment variables:
+// `DENOX_CONNECT_TIMEOUT`, `DENOX_CA_FILE`, `DENO_CERT`, `DENOX_CLIENT_CERT`, `DENOX_CLIENT_KEY` and `DENOX_INSECURE`
+func OptionsFromEnv() Options {
+	options := Options{
+		ConnectTimeout: DefaultConnectTimeout,
+		Proxy:          config.Default().Get("proxy"),
+		ClientCert:     os.Getenv("DENOX_CLIENT_CERT"),
+		ClientKey:      os.Getenv("DENOX_CLIENT_KEY"),
+		Insecure:       os.Getenv("DENOX_INSECURE") != "",
+	}
+
+	if timeout, err := time.ParseDuration(os.Getenv("DENOX_CONNECT_TIMEOUT")); err == nil {
+		options.ConnectTimeout = timeout
+	}
+
+	// the CA file of Deno is also trusted
+	for _, name := range []string{"DENOX_CA_FILE", "DENO_CERT"} {
+		for _, file := range filepath.SplitList(os.Getenv(name)) {
+			if file = strings.TrimSpace(file); file != "" {
+				options.CAFiles = append(options.CAFiles, file)
+			}
+		}
+	}
+
+	return options
+}
+
+// New create a HTTP client with the options.
+// there is no timeout of the whole request, because the file may be large
+func New(options Options) (*http.Client, error) {
+	proxy := http.ProxyFromEnvironment
+
+	if options.Proxy != "" {
+		proxyURL, err := url.Parse(options.Proxy)
+
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid proxy `%s`", options.Proxy)
+		}
+
+		noProxy := getNoProxy()
+
+		// the hosts in `NO_PROXY` still bypass the proxy, eg. the internal mirror
+		proxy = func(r *http.Request) (*url.URL, error) {
+			if !useProxy(noProxy, r.URL) {
+				return nil, nil
+			}
+
+			return proxyURL, nil
+		}
+	}
+
+	tlsConfig, err := newTLSConfig(options)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &http.Client{
+		Transport: &http.Transport{
+			Proxy: proxy,
+			DialContext: (&net.Dialer{
+				Timeout:   options.ConnectTimeout,
+				KeepAlive: 30 * time.Second,
+			}).DialContext,
+			TLSClientConfig:       tlsConfig,
+			TLSHandshakeTimeout:   options.ConnectTimeout,
+			ResponseHeaderTimeout: options.ConnectTimeout,
+			IdleConnTimeout:       90 * time.Second,
+		},
+	}, nil
+}
+
+func getNoProxy() string {
+	if noProxy := os.Getenv("NO_PROXY"); noProxy != "" {
+		return noProxy
+	}
+
+	return os.Getenv("no_proxy")
+}
+
+// whether the request to u uses the proxy with the `NO_PROXY` list, like the one of Go.
+// the entry is a host name which matches its sub domains too, a domain starts with `.`, an IP, a CIDR, or `*` for all.
+// a port can be added to match the port only
+func useProxy(noProxy string, u *url.URL) bool {
+	host := strings.ToLower(u.Hostname())
+	port := u.Port()
+
+	if port == "" {
+		if u.Scheme == "https" {
+			port = "443"
+		} else {
+			port = "80"
+		}
+	}
+
+	if host == "localhost" {
+		return false
+	}
+
+	ip := net.ParseIP(host)
+
+	if ip != nil && ip.IsLoopback() {
+		return false
+	}
+
+	for _, entry := range strings.Split(noProxy, ",") {
+		entry = strings.ToLower(strings.TrimSpace(entry))
+
+		if entry == "" {
+			continue
+		}
+
+		if entry == "*" {
+			return false
+		}
+
+		if _, ipNet, err := net.ParseCIDR(entry); err == nil {
+			if ip != nil && ipNet.Contains(ip) {
+				return false
+			}
+
+			continue
+		}
+
+		entryHost, entryPort := entry, ""
+
+		if h, p, err := net.SplitHostPort(entry); err == nil {
+			entryHost, entryPort = h, p
+		}
+
+		if entryPort != "" && entryPort != port {
+			continue
+		}
+
+		if entryIP := net.ParseIP(entryHost); entryIP != nil {
+			if ip != nil && entryIP.Equal(ip) {
+				return false
+			}
+
+			continue
+		}
+
+		domain := strings.TrimPrefix(entryHost, "*")
+
+		if strings.HasPrefix(domain, ".") {
+			if strings.HasSuffix(host, domain) {
+				return false
+			}
+
+			continue
+		}
+
+		if host == domain || strings.HasSuffix(host, "."+domain) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func newTLSConfig(options Options) (*tls.Config, error) {
+	config := &tls.Config{}
+
+	if len(options.CAFiles) > 0 {
+		pool, err := x509.SystemCertPool()
+
+		// there is no system cert pool on Windows with Go 1.13
+		if err != nil || pool == nil {
+			pool = x509.NewCertPool()
+		}
+
+		for _, file := range options.CAFiles {
+			b, err := ioutil.ReadFile(file)
+
+			if err != nil {
+				return nil, errors.Wrapf(err, "read CA file `%s` fail", file)
+			}
+
+			if !pool.AppendCertsFromPEM(b) {
+				return nil, errors.Errorf("no certificate found in CA file `%s`", file)
+			}
+		}
+
+		config.RootCAs = pool
+	}
+
+	if options.ClientCert != "" || options.ClientKey != "" {
+		cert, err := tls.LoadX509KeyPair(options.ClientCert, options.ClientKey)
+
+		if err != nil {
+			return nil, errors.Wrap(err, "load client certificate fail")
+		}
+
+		config.Certificates = []tls.Certificate{cert}
+	}
+
+	if options.Insecure {
+		logger.Warnf("!!! TLS certificate verification is DISABLED by DENOX_INSECURE !!!")
+		logger.Warnf("!!! the downloaded Deno can be tampered by anyone in the middle, never use it in production !!!")
+		config.InsecureSkipVerify = true
+	}
+
+	return config, nil
+}
+
+var (
+	defaultClient    *http.Client
+	defaultClientErr error
+	defaultOnce      sync.Once
+)
+
+// Default returns the shared HTTP client with the options from environment variables
+func Default() (*http.Client, error) {
+	defaultOnce.Do(func() {
+		defaultClient, defaultClientErr = New(OptionsFromEnv())
+	})
+
+	return defaultClient, defaultClientErr
+}
@@ -0,0 +1,94 @@
+package httpclient
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/axetroy/denox/internal/testutil"
+)
+
+func TestUseProxy(t *testing.T) {
+	tests := []struct {
+		noProxy string
+		url     string
+		expect  bool
+	}{
+		{"", "https://github.com/denoland/deno", true},
+		{"", "http://localhost:8080/deno.zip", false},
+		{"", "http://127.0.0.1/deno.zip", false},
+		{"*", "https://github.com/denoland/deno", false},
+		{"mirror.internal", "https://mirror.internal/deno.zip", false},
+		{"mirror.internal", "https://cdn.mirror.internal/deno.zip", false},
+		{"mirror.internal", "https://evilmirror.internal/deno.zip", true},
+		{".internal", "https://mirror.internal/deno.zip", false},
+		{".internal", "https://internal/deno.zip", true},
+		{"*.internal", "https://mirror.internal/deno.zip", false},
+		{"github.com, MIRROR.internal ", "https://mirror.internal/deno.zip", false},
+		{"mirror.internal:8080", "http://mirror.internal:8080/deno.zip", false},
+		{"mirror.internal:8080", "http://mirror.internal/deno.zip", true},
+		{"mirror.internal:443", "https://mirror.internal/deno.zip", false},
+		{"10.0.0.1", "http://10.0.0.1/deno.zip", false},
+		{"10.0.0.1", "http://10.0.0.2/deno.zip", true},
+		{"10.0.0.0/8", "http://10.1.2.3/deno.zip", false},
+		{"10.0.0.0/8", "http://192.168.1.1/deno.zip", true},
+		{"::1/128,fd00::/8", "http://[fd00::1]/deno.zip", false},
+	}
+
+	for _, test := range tests {
+		u, err := url.Parse(test.url)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if actual := useProxy(test.noProxy, u); actual != test.expect {
+			t.Errorf("expect useProxy(`%s`, %s) to be %v, got %v", test.noProxy, test.url, test.expect, actual)
+		}
+	}
+}
+
+func TestNewProxy(t *testing.T) {
+	defer testutil.Setenv(t, map[string]string{"NO_PROXY": "mirror.internal", "no_proxy": ""})()
+
+	client, err := New(Options{Proxy: "http://proxy:3128"})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	proxy := client.Transport.(*http.Transport).Proxy
+
+	tests := map[string]string{
+		"https://github.com/denoland/deno": "http://proxy:3128",
+		"https://mirror.internal/deno.zip": "",
+	}
+
+	for rawURL, expect := range tests {
+		r, err := http.NewRequest(http.MethodGet, rawURL, nil)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		proxyURL, err := proxy(r)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		actual := ""
+
+		if proxyURL != nil {
+			actual = proxyURL.String()
+		}
+
+		if actual != expect {
+			t.Errorf("expect proxy `%s` for %s, got `%s`", expect, rawURL, actual)
+		}
+	}
+
+	if _, err := New(Options{Proxy: "http://[::1"}); err == nil {
+		t.Error("expect error for the invalid proxy, got nil")
+	}
+}
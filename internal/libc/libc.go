@@ -0,0 +1,128 @@
+package libc
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	// `glibc 2.31` from `getconf GNU_LIBC_VERSION`
+	getconfVersionRegexp = regexp.MustCompile(`^glibc\s+(\d+\.\d+)`)
+	// `ldd (Ubuntu GLIBC 2.31-0ubuntu9) 2.31` from `ldd --version`
+	lddVersionRegexp = regexp.MustCompile(`(\d+\.\d+)\s*$`)
+	// `Version 1.2.2` from `ldd` of musl
+	muslVersionRegexp = regexp.MustCompile(`(?m)^Version\s+(\S+)`)
+	// `libc-2.31.so` before glibc 2.34
+	libcFileRegexp = regexp.MustCompile(`libc-(\d+\.\d+)\.so$`)
+)
+
+// Family of the C library
+type Family string
+
+const (
+	Glibc   Family = "glibc"
+	Musl    Family = "musl"
+	Unknown Family = ""
+)
+
+// Info of the C library of the system
+type Info struct {
+	Family Family
+	// eg `2.31` for glibc, empty if unknown
+	Version string
+	// the ELF interpreter of the system binaries, eg `/lib64/ld-linux-x86-64.so.2`
+	Interpreter string
+	// the glibc loader exists on a musl system, eg Alpine with gcompat
+	GlibcCompat bool
+}
+
+func (i *Info) String() string {
+	if i.Family == Unknown {
+		return "unknown libc"
+	}
+
+	if i.Version == "" {
+		return string(i.Family)
+	}
+
+	return string(i.Family) + " " + i.Version
+}
+
+// get the family from the ELF interpreter, eg `/lib/ld-musl-x86_64.so.1` or `/lib64/ld-linux-x86-64.so.2`
+func getFamily(interpreter string) Family {
+	switch {
+	case strings.Contains(interpreter, "musl"):
+		return Musl
+	case strings.Contains(interpreter, "ld-linux"):
+		return Glibc
+	default:
+		return Unknown
+	}
+}
+
+// parse the output of `getconf GNU_LIBC_VERSION`
+func parseGetconfVersion(output string) string {
+	if matches := getconfVersionRegexp.FindStringSubmatch(strings.TrimSpace(output)); len(matches) == 2 {
+		return matches[1]
+	}
+
+	return ""
+}
+
+// parse the first line of `ldd --version` of glibc
+func parseLddVersion(output string) string {
+	firstLine := strings.SplitN(output, "\n", 2)[0]
+
+	if matches := lddVersionRegexp.FindStringSubmatch(strings.TrimSpace(firstLine)); len(matches) == 2 {
+		return matches[1]
+	}
+
+	return ""
+}
+
+// parse the output of `ldd` of musl
+func parseMuslVersion(output string) string {
+	if matches := muslVersionRegexp.FindStringSubmatch(output); len(matches) == 2 {
+		return matches[1]
+	}
+
+	return ""
+}
+
+// parse the version from the file name of glibc, eg `/lib/x86_64-linux-gnu/libc-2.31.so`
+func parseLibcFilename(file string) string {
+	if matches := libcFileRegexp.FindStringSubmatch(file); len(matches) == 2 {
+		return matches[1]
+	}
+
+	return ""
+}
+
+// CompareVersion compare the versions like `2.17` and `2.31`, returns -1, 0 or 1
+func CompareVersion(a string, b string) int {
+	pa := strings.Split(a, ".")
+	pb := strings.Split(b, ".")
+
+	for i := 0; i < len(pa) || i < len(pb); i++ {
+		var na, nb int
+
+		if i < len(pa) {
+			na, _ = strconv.Atoi(pa[i])
+		}
+
+		if i < len(pb) {
+			nb, _ = strconv.Atoi(pb[i])
+		}
+
+		if na != nb {
+			if na < nb {
+				return -1
+			}
+
+			return 1
+		}
+	}
+
+	return 0
+}
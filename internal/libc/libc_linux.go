@@ -0,0 +1,117 @@
+// +build linux
+
+package libc
+
+import (
+	"debug/elf"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// the system binaries to read the ELF interpreter from
+var systemBinaries = []string{"/bin/sh", "/usr/bin/env", "/bin/ls"}
+
+// Detect the C library of the system
+func Detect() (*Info, error) {
+	info := &Info{}
+
+	for _, file := range systemBinaries {
+		if interpreter, err := readInterpreter(file); err == nil && interpreter != "" {
+			info.Interpreter = interpreter
+			break
+		}
+	}
+
+	info.Family = getFamily(info.Interpreter)
+
+	switch {
+	case info.Family != Unknown:
+		// found from the interpreter
+	case exists("/lib/ld-musl-*.so.1"):
+		info.Family = Musl
+	case exists("/lib*/ld-linux*.so.*"), exists("/lib/*-linux-gnu/ld-linux*.so.*"):
+		info.Family = Glibc
+	default:
+		return info, errors.New("detect libc fail: no ELF interpreter found")
+	}
+
+	switch info.Family {
+	case Glibc:
+		info.Version = getGlibcVersion()
+	case Musl:
+		info.Version = getMuslVersion()
+		info.GlibcCompat = exists("/lib*/ld-linux*.so.*")
+	}
+
+	return info, nil
+}
+
+// read the interpreter from the `PT_INTERP` program header, eg `/lib/ld-musl-x86_64.so.1`
+func readInterpreter(file string) (string, error) {
+	f, err := elf.Open(file)
+
+	if err != nil {
+		return "", err
+	}
+
+	defer f.Close()
+
+	for _, prog := range f.Progs {
+		if prog.Type != elf.PT_INTERP {
+			continue
+		}
+
+		b := make([]byte, prog.Filesz)
+
+		if _, err := prog.ReadAt(b, 0); err != nil {
+			return "", err
+		}
+
+		return strings.TrimRight(string(b), "\x00"), nil
+	}
+
+	// a static binary
+	return "", nil
+}
+
+func getGlibcVersion() string {
+	if output, err := exec.Command("getconf", "GNU_LIBC_VERSION").Output(); err == nil {
+		if version := parseGetconfVersion(string(output)); version != "" {
+			return version
+		}
+	}
+
+	if output, err := exec.Command("ldd", "--version").Output(); err == nil {
+		if version := parseLddVersion(string(output)); version != "" {
+			return version
+		}
+	}
+
+	for _, pattern := range []string{"/lib*/libc-*.so", "/lib/*-linux-gnu/libc-*.so"} {
+		files, _ := filepath.Glob(pattern)
+
+		for _, file := range files {
+			if version := parseLibcFilename(file); version != "" {
+				return version
+			}
+		}
+	}
+
+	return ""
+}
+
+func getMuslVersion() string {
+	// `ldd` of musl prints the version to stderr and exits with 1
+	output, _ := exec.Command("ldd").CombinedOutput()
+
+	return parseMuslVersion(string(output))
+}
+
+func exists(pattern string) bool {
+	files, err := filepath.Glob(pattern)
+
+	return err == nil && len(files) > 0
+}
@@ -0,0 +1,33 @@
+//go:build linux
+// +build linux
+
+package libc
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestReadInterpreter(t *testing.T) {
+	for _, file := range systemBinaries {
+		if _, err := os.Stat(file); err != nil {
+			continue
+		}
+
+		interpreter, err := readInterpreter(file)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// a static binary has no interpreter, eg busybox
+		if interpreter != "" && (!strings.HasPrefix(interpreter, "/") || getFamily(interpreter) == Unknown) {
+			t.Fatalf("expect the interpreter of glibc or musl for `%s`, got `%s`", file, interpreter)
+		}
+	}
+
+	if _, err := readInterpreter("libc_linux_test.go"); err == nil {
+		t.Fatal("expect error for the file which is not ELF, got nil")
+	}
+}
@@ -0,0 +1,8 @@
+// +build !linux
+
+package libc
+
+// Detect the C library of the system, it only makes sense on Linux
+func Detect() (*Info, error) {
+	return &Info{}, nil
+}
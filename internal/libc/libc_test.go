@@ -0,0 +1,65 @@
+package libc
+
+import "testing"
+
+func TestGetFamily(t *testing.T) {
+	tests := map[string]Family{
+		"/lib64/ld-linux-x86-64.so.2": Glibc,
+		"/lib/ld-linux-aarch64.so.1":  Glibc,
+		"/lib/ld-musl-x86_64.so.1":    Musl,
+		"/lib/ld-musl-aarch64.so.1":   Musl,
+		"":                            Unknown,
+		"/system/bin/linker64":        Unknown,
+	}
+
+	for interpreter, expected := range tests {
+		if actual := getFamily(interpreter); actual != expected {
+			t.Errorf("expect `%s` for `%s`, got `%s`", expected, interpreter, actual)
+		}
+	}
+}
+
+func TestParseVersion(t *testing.T) {
+	tests := []struct {
+		name     string
+		parse    func(string) string
+		output   string
+		expected string
+	}{
+		{"getconf", parseGetconfVersion, "glibc 2.31\n", "2.31"},
+		{"getconf of musl", parseGetconfVersion, "getconf: GNU_LIBC_VERSION: unknown variable\n", ""},
+		{"ldd of Ubuntu", parseLddVersion, "ldd (Ubuntu GLIBC 2.31-0ubuntu9.9) 2.31\nCopyright (C) 2020 Free Software Foundation, Inc.\n", "2.31"},
+		{"ldd of CentOS", parseLddVersion, "ldd (GNU libc) 2.17\nCopyright (C) 2012 Free Software Foundation, Inc.\n", "2.17"},
+		{"ldd of Debian", parseLddVersion, "ldd (Debian GLIBC 2.36-9+deb12u4) 2.36\n", "2.36"},
+		{"ldd without version", parseLddVersion, "ldd: unknown option\n", ""},
+		{"ldd of musl", parseMuslVersion, "musl libc (x86_64)\nVersion 1.2.4\nDynamic Program Loader\nUsage: ldd [options] [--] pathname\n", "1.2.4"},
+		{"ldd of musl without version", parseMuslVersion, "Usage: ldd [options] [--] pathname\n", ""},
+		{"libc file", parseLibcFilename, "/lib/x86_64-linux-gnu/libc-2.31.so", "2.31"},
+		{"libc file since glibc 2.34", parseLibcFilename, "/lib/x86_64-linux-gnu/libc.so.6", ""},
+	}
+
+	for _, test := range tests {
+		if actual := test.parse(test.output); actual != test.expected {
+			t.Errorf("expect `%s` for %s, got `%s`", test.expected, test.name, actual)
+		}
+	}
+}
+
+func TestCompareVersion(t *testing.T) {
+	tests := []struct {
+		a, b     string
+		expected int
+	}{
+		{"2.17", "2.18", -1},
+		{"2.31", "2.18", 1},
+		{"2.9", "2.18", -1},
+		{"2.18", "2.18", 0},
+		{"2.18", "2.18.0", 0},
+	}
+
+	for _, test := range tests {
+		if actual := CompareVersion(test.a, test.b); actual != test.expected {
+			t.Errorf("expect %d for %s and %s, got %d", test.expected, test.a, test.b, actual)
+		}
+	}
+}
@@ -0,0 +1,47 @@
+package lock
+
+import (
+	"os"
+
+	"github.com/axetroy/denox/internal/logger"
+	"github.com/pkg/errors"
+)
+
+// Lock is an exclusive lock across processes, base on a lock file
+type Lock struct {
+	file *os.File
+}
+
+// Acquire the lock of the file, it blocks until the lock is released by other process.
+// the lock will be released automatically if the process exits
+func Acquire(file string) (*Lock, error) {
+	f, err := os.OpenFile(file, os.O_CREATE|os.O_RDWR, 0644)
+
+	if err != nil {
+		return nil, errors.Wrapf(err, "open lock file `%s` fail", file)
+	}
+
+	if ok, err := lockFile(f, false); err != nil {
+		_ = f.Close()
+		return nil, errors.Wrapf(err, "lock file `%s` fail", file)
+	} else if !ok {
+		logger.Debugf("waiting for the lock `%s` held by another process", file)
+
+		if _, err := lockFile(f, true); err != nil {
+			_ = f.Close()
+			return nil, errors.Wrapf(err, "lock file `%s` fail", file)
+		}
+	}
+
+	return &Lock{file: f}, nil
+}
+
+// Release the lock
+func (l *Lock) Release() error {
+	if err := unlockFile(l.file); err != nil {
+		_ = l.file.Close()
+		return errors.Wrapf(err, "unlock file `%s` fail", l.file.Name())
+	}
+
+	return l.file.Close()
+}
@@ -0,0 +1,31 @@
+// +build !windows
+
+package lock
+
+import (
+	"os"
+	"syscall"
+)
+
+// lock the file, returns false if it is held by others and block is false
+func lockFile(f *os.File, block bool) (bool, error) {
+	how := syscall.LOCK_EX
+
+	if !block {
+		how |= syscall.LOCK_NB
+	}
+
+	if err := syscall.Flock(int(f.Fd()), how); err != nil {
+		if err == syscall.EWOULDBLOCK {
+			return false, nil
+		}
+
+		return false, err
+	}
+
+	return true, nil
+}
+
+func unlockFile(f *os.File) error {
+	return syscall.Flock(int(f.Fd()), syscall.LOCK_UN)
+}
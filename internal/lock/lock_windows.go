@@ -0,0 +1,58 @@
+// +build windows
+
+package lock
+
+import (
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+var (
+	modKernel32      = syscall.NewLazyDLL("kernel32.dll")
+	procLockFileEx   = modKernel32.NewProc("LockFileEx")
+	procUnlockFileEx = modKernel32.NewProc("UnlockFileEx")
+)
+
+// https://docs.microsoft.com/en-us/windows/win32/api/fileapi/nf-fileapi-lockfileex
+const (
+	lockfileFailImmediately = 0x1
+	lockfileExclusiveLock   = 0x2
+
+	errorLockViolation syscall.Errno = 33
+)
+
+// lock the file, returns false if it is held by others and block is false
+func lockFile(f *os.File, block bool) (bool, error) {
+	flags := uintptr(lockfileExclusiveLock)
+
+	if !block {
+		flags |= lockfileFailImmediately
+	}
+
+	overlapped := new(syscall.Overlapped)
+
+	r, _, err := procLockFileEx.Call(f.Fd(), flags, 0, 1, 0, uintptr(unsafe.Pointer(overlapped)))
+
+	if r == 0 {
+		if err == errorLockViolation {
+			return false, nil
+		}
+
+		return false, err
+	}
+
+	return true, nil
+}
+
+func unlockFile(f *os.File) error {
+	overlapped := new(syscall.Overlapped)
+
+	r, _, err := procUnlockFileEx.Call(f.Fd(), 0, 1, 0, uintptr(unsafe.Pointer(overlapped)))
+
+	if r == 0 {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,28 @@
+package logger
+
+import (
+	"fmt"
+	"io"
+	"os"
+)
+
+var (
+	// print the debug message or not, enable it with environment variable `DENOX_VERBOSE`
+	Verbose = os.Getenv("DENOX_VERBOSE") != ""
+	// all message of denox goes to stderr, so that it won't break the output of Deno
+	Output io.Writer = os.Stderr
+)
+
+// Debugf print the message only in verbose mode
+func Debugf(format string, args ...interface{}) {
+	if !Verbose {
+		return
+	}
+
+	_, _ = fmt.Fprintf(Output, "[denox] "+format+"\n", args...)
+}
+
+// Warnf print the warning message
+func Warnf(format string, args ...interface{}) {
+	_, _ = fmt.Fprintf(Output, "[denox] warning: "+format+"\n", args...)
+}
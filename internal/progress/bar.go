@@ -0,0 +1,154 @@
+package progress
+
+import (
+	"fmt"
+	"io"
+	"sync/atomic"
+	"time"
+
+	"github.com/cheggaaa/pb/v3"
+)
+
+const (
+	// the min interval of the progress events in plain and JSON mode
+	eventInterval = time.Second
+)
+
+// Bar shows the progress of a download. it is safe for concurrent use
+type Bar interface {
+	// wrap the reader, the bytes read from it are counted
+	NewProxyReader(reader io.Reader) io.Reader
+	// add the bytes, it can be negative when the downloaded data is dropped
+	Add64(n int64)
+	// set the bytes downloaded, eg it is reset to 0 when the downloaded data is dropped
+	SetCurrent(current int64)
+	// set the total bytes when it is known
+	SetTotal(total int64)
+	Finish()
+}
+
+// NewBar create a Bar of the file, total is -1 if it is unknown
+func (r *Reporter) NewBar(name string, total int64, current int64) Bar {
+	if r == nil {
+		return noopBar{}
+	}
+
+	switch r.Mode {
+	case ModeBar:
+		tmpl := fmt.Sprintf(`{{string . "prefix"}}{{ green "%s" }} {{counters . }} {{ bar . "[" "=" ">" "-" "]"}} {{percent . }} {{speed . }}{{string . "suffix"}}`, name)
+
+		bar := pb.New64(total).SetTemplate(pb.ProgressBarTemplate(tmpl))
+
+		bar.SetWriter(r.Output)
+		bar.SetCurrent(current)
+		bar.Start()
+
+		return &pbBar{bar: bar}
+	case ModePlain, ModeJSON:
+		return &eventBar{reporter: r, name: name, total: total, current: current, last: time.Now().UnixNano()}
+	}
+
+	return noopBar{}
+}
+
+type noopBar struct{}
+
+func (noopBar) NewProxyReader(reader io.Reader) io.Reader { return reader }
+func (noopBar) Add64(int64)                               {}
+func (noopBar) SetCurrent(int64)                          {}
+func (noopBar) SetTotal(int64)                            {}
+func (noopBar) Finish()                                   {}
+
+// the progress bar of pb
+type pbBar struct {
+	bar *pb.ProgressBar
+}
+
+func (b *pbBar) NewProxyReader(reader io.Reader) io.Reader {
+	return b.bar.NewProxyReader(reader)
+}
+
+func (b *pbBar) Add64(n int64) {
+	b.bar.Add64(n)
+}
+
+func (b *pbBar) SetCurrent(current int64) {
+	b.bar.SetCurrent(current)
+}
+
+func (b *pbBar) SetTotal(total int64) {
+	b.bar.SetTotal(total)
+}
+
+func (b *pbBar) Finish() {
+	b.bar.Finish()
+}
+
+// report the progress as `download_progress` events at most once per eventInterval
+type eventBar struct {
+	// the atomic fields go first, so that they are 64-bit aligned on 32-bit platforms
+	current int64
+	total   int64
+	// the time of the last event, in unix nano
+	last     int64
+	reporter *Reporter
+	name     string
+}
+
+func (b *eventBar) NewProxyReader(reader io.Reader) io.Reader {
+	return &proxyReader{reader: reader, bar: b}
+}
+
+func (b *eventBar) Add64(n int64) {
+	atomic.AddInt64(&b.current, n)
+
+	now := time.Now().UnixNano()
+	last := atomic.LoadInt64(&b.last)
+
+	// another goroutine may report at the same time
+	if now-last < int64(eventInterval) || !atomic.CompareAndSwapInt64(&b.last, last, now) {
+		return
+	}
+
+	b.report()
+}
+
+func (b *eventBar) SetCurrent(current int64) {
+	atomic.StoreInt64(&b.current, current)
+}
+
+func (b *eventBar) SetTotal(total int64) {
+	atomic.StoreInt64(&b.total, total)
+}
+
+func (b *eventBar) Finish() {
+	b.report()
+}
+
+func (b *eventBar) report() {
+	current := atomic.LoadInt64(&b.current)
+
+	total := atomic.LoadInt64(&b.total)
+
+	fields := Fields{"file": b.name, "current": current}
+
+	if total > 0 {
+		fields["total"] = total
+		fields["percent"] = current * 100 / total
+	}
+
+	b.reporter.Event("download_progress", fields)
+}
+
+type proxyReader struct {
+	reader io.Reader
+	bar    Bar
+}
+
+func (r *proxyReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+
+	r.bar.Add64(int64(n))
+
+	return n, err
+}
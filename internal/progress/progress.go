@@ -0,0 +1,140 @@
+package progress
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/axetroy/denox/internal/config"
+)
+
+// Mode of the progress output
+type Mode string
+
+const (
+	// an interactive progress bar
+	ModeBar Mode = "bar"
+	// a line of text for each event, for the logs of CI
+	ModePlain Mode = "plain"
+	// a JSON object per line for each event, for the machine
+	ModeJSON Mode = "json"
+	// no progress output
+	ModeNone Mode = "none"
+)
+
+// Fields of the event
+type Fields map[string]interface{}
+
+// Reporter writes the progress of denox. a nil Reporter reports nothing
+type Reporter struct {
+	Mode   Mode
+	Output io.Writer
+	// prevent the lines from interleaving
+	mu sync.Mutex
+}
+
+var (
+	defaultReporter *Reporter
+	defaultOnce     sync.Once
+)
+
+// New create a Reporter writes to output
+func New(mode Mode, output io.Writer) *Reporter {
+	return &Reporter{Mode: mode, Output: output}
+}
+
+// Default returns the Reporter writes to stderr, the mode is read from config `progress`.
+// if it is not set, the progress bar is shown only when stderr is a terminal
+func Default() *Reporter {
+	defaultOnce.Do(func() {
+		defaultReporter = New(getMode(), os.Stderr)
+	})
+
+	return defaultReporter
+}
+
+func getMode() Mode {
+	switch mode := Mode(strings.ToLower(config.Default().Get("progress"))); mode {
+	case ModeBar, ModePlain, ModeJSON, ModeNone:
+		return mode
+	}
+
+	if isTerminal(os.Stderr) {
+		return ModeBar
+	}
+
+	return ModeNone
+}
+
+func isTerminal(f *os.File) bool {
+	stat, err := f.Stat()
+
+	if err != nil {
+		return false
+	}
+
+	return stat.Mode()&os.ModeCharDevice != 0
+}
+
+// Event reports an event, eg `resolve`, `download_start`, `extract` and `run`.
+// it is only printed in plain and JSON mode, the progress bar shows nothing but the bar
+func (r *Reporter) Event(name string, fields Fields) {
+	if r == nil {
+		return
+	}
+
+	switch r.Mode {
+	case ModePlain:
+		keys := make([]string, 0, len(fields))
+
+		for key := range fields {
+			keys = append(keys, key)
+		}
+
+		sort.Strings(keys)
+
+		line := "[denox] " + name
+
+		for _, key := range keys {
+			value := fmt.Sprintf("%v", fields[key])
+
+			if strings.ContainsAny(value, " \t\"") {
+				value = strconv.Quote(value)
+			}
+
+			line += " " + key + "=" + value
+		}
+
+		r.println(line)
+	case ModeJSON:
+		event := Fields{}
+
+		for key, value := range fields {
+			event[key] = value
+		}
+
+		event["event"] = name
+		event["time"] = time.Now().Format(time.RFC3339Nano)
+
+		b, err := json.Marshal(event)
+
+		if err != nil {
+			return
+		}
+
+		r.println(string(b))
+	}
+}
+
+func (r *Reporter) println(line string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	_, _ = fmt.Fprintln(r.Output, line)
+}
@@ -0,0 +1,340 @@
+package semver
+
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+var (
+	ErrInvalidRange = errors.New("invalid version range")
+)
+
+type operator string
+
+const (
+	opEQ  operator = "="
+	opGT  operator = ">"
+	opGTE operator = ">="
+	opLT  operator = "<"
+	opLTE operator = "<="
+)
+
+type comparator struct {
+	op      operator
+	version *Version
+}
+
+func (c comparator) match(v *Version) bool {
+	r := v.Compare(c.version)
+
+	switch c.op {
+	case opEQ:
+		return r == 0
+	case opGT:
+		return r > 0
+	case opGTE:
+		return r >= 0
+	case opLT:
+		return r < 0
+	case opLTE:
+		return r <= 0
+	}
+
+	return false
+}
+
+// Range is a set of version constraints, eg `^1.2`, `~1.4.0`, `1.x`, `>=1.3 <2` or `1.2 || ^2`
+type Range struct {
+	raw string
+	// the range matches if any of the sets matches.
+	// a set matches if all of its comparators match
+	sets [][]comparator
+}
+
+// ParseRange parse a version range. `latest` and `*` match any stable version
+func ParseRange(s string) (*Range, error) {
+	raw := strings.TrimSpace(s)
+
+	r := &Range{raw: raw}
+
+	for _, part := range strings.Split(raw, "||") {
+		set, err := parseSet(part)
+
+		if err != nil {
+			return nil, errors.Wrapf(err, "`%s`", raw)
+		}
+
+		r.sets = append(r.sets, set)
+	}
+
+	return r, nil
+}
+
+func (r *Range) String() string {
+	return r.raw
+}
+
+// Contains reports whether the version satisfies the range.
+// Pre-release versions only match when the range explicitly mentions a
+// pre-release of the same major.minor.patch
+func (r *Range) Contains(v *Version) bool {
+	for _, set := range r.sets {
+		if matchSet(set, v) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Filter returns the versions that satisfy the range
+func (r *Range) Filter(versions []*Version) []*Version {
+	result := make([]*Version, 0)
+
+	for _, v := range versions {
+		if r.Contains(v) {
+			result = append(result, v)
+		}
+	}
+
+	return result
+}
+
+func matchSet(set []comparator, v *Version) bool {
+	for _, c := range set {
+		if !c.match(v) {
+			return false
+		}
+	}
+
+	if v.Prerelease == "" {
+		return true
+	}
+
+	for _, c := range set {
+		if c.version.Prerelease != "" && c.version.Major == v.Major && c.version.Minor == v.Minor && c.version.Patch == v.Patch {
+			return true
+		}
+	}
+
+	return false
+}
+
+func parseSet(s string) ([]comparator, error) {
+	tokens := strings.Fields(s)
+
+	// an empty alternative, eg `^1 || `, is a typo rather than any version
+	if len(tokens) == 0 {
+		return nil, ErrInvalidRange
+	}
+
+	// hyphen range, eg `1.2 - 1.4`
+	if len(tokens) == 3 && tokens[1] == "-" {
+		from, err := expand(opGTE, tokens[0])
+
+		if err != nil {
+			return nil, err
+		}
+
+		to, err := expand(opLTE, tokens[2])
+
+		if err != nil {
+			return nil, err
+		}
+
+		return append(from, to...), nil
+	}
+
+	set := make([]comparator, 0)
+
+	for i := 0; i < len(tokens); i++ {
+		token := tokens[i]
+
+		// allow a space between operator and version, eg `>= 1.3`
+		if strings.Trim(token, "<>=~^") == "" && i+1 < len(tokens) {
+			i++
+			token += tokens[i]
+		}
+
+		op, version := splitOperator(token)
+
+		comparators, err := expand(op, version)
+
+		if err != nil {
+			return nil, err
+		}
+
+		set = append(set, comparators...)
+	}
+
+	return set, nil
+}
+
+func splitOperator(token string) (operator, string) {
+	for _, op := range []string{">=", "<=", "~>", ">", "<", "=", "~", "^"} {
+		if strings.HasPrefix(token, op) {
+			version := token[len(op):]
+
+			if op == "~>" {
+				op = "~"
+			}
+
+			return operator(op), version
+		}
+	}
+
+	return "", token
+}
+
+// partial is a version where the trailing parts may be omitted or wildcards, eg `1`, `1.2`, `1.x`
+type partial struct {
+	major, minor, patch int
+	prerelease          string
+	// number of specified parts
+	size int
+}
+
+func (p partial) version() *Version {
+	return &Version{Major: p.major, Minor: p.minor, Patch: p.patch, Prerelease: p.prerelease}
+}
+
+func parsePartial(s string) (*partial, error) {
+	raw := strings.TrimPrefix(strings.TrimSpace(s), "v")
+
+	p := &partial{}
+
+	// an operator without version, eg `>=`
+	if raw == "" {
+		return nil, ErrInvalidRange
+	}
+
+	if raw == "latest" {
+		return p, nil
+	}
+
+	if i := strings.Index(raw, "+"); i >= 0 {
+		raw = raw[:i]
+	}
+
+	if i := strings.Index(raw, "-"); i >= 0 {
+		p.prerelease = raw[i+1:]
+		raw = raw[:i]
+	}
+
+	parts := strings.Split(raw, ".")
+
+	if len(parts) > 3 {
+		return nil, ErrInvalidRange
+	}
+
+	numbers := []*int{&p.major, &p.minor, &p.patch}
+
+	for i, part := range parts {
+		if part == "x" || part == "X" || part == "*" {
+			break
+		}
+
+		n, err := parseNumber(part)
+
+		if err != nil {
+			return nil, ErrInvalidRange
+		}
+
+		*numbers[i] = n
+		p.size++
+	}
+
+	// a pre-release only make sense on a full version
+	if p.prerelease != "" && p.size != 3 {
+		return nil, ErrInvalidRange
+	}
+
+	return p, nil
+}
+
+// expand an operator with a partial version into primitive comparators
+func expand(op operator, s string) ([]comparator, error) {
+	p, err := parsePartial(s)
+
+	if err != nil {
+		return nil, err
+	}
+
+	none := []comparator{{op: opLT, version: &Version{}}}
+	all := []comparator{}
+
+	between := func(from, to *Version) []comparator {
+		return []comparator{{op: opGTE, version: from}, {op: opLT, version: to}}
+	}
+
+	switch op {
+	case "", opEQ:
+		switch p.size {
+		case 0:
+			return all, nil
+		case 1:
+			return between(p.version(), &Version{Major: p.major + 1}), nil
+		case 2:
+			return between(p.version(), &Version{Major: p.major, Minor: p.minor + 1}), nil
+		default:
+			return []comparator{{op: opEQ, version: p.version()}}, nil
+		}
+	case opGT:
+		switch p.size {
+		case 0:
+			return none, nil
+		case 1:
+			return []comparator{{op: opGTE, version: &Version{Major: p.major + 1}}}, nil
+		case 2:
+			return []comparator{{op: opGTE, version: &Version{Major: p.major, Minor: p.minor + 1}}}, nil
+		default:
+			return []comparator{{op: opGT, version: p.version()}}, nil
+		}
+	case opGTE:
+		if p.size == 0 {
+			return all, nil
+		}
+
+		return []comparator{{op: opGTE, version: p.version()}}, nil
+	case opLT:
+		if p.size == 0 {
+			return none, nil
+		}
+
+		return []comparator{{op: opLT, version: p.version()}}, nil
+	case opLTE:
+		switch p.size {
+		case 0:
+			return all, nil
+		case 1:
+			return []comparator{{op: opLT, version: &Version{Major: p.major + 1}}}, nil
+		case 2:
+			return []comparator{{op: opLT, version: &Version{Major: p.major, Minor: p.minor + 1}}}, nil
+		default:
+			return []comparator{{op: opLTE, version: p.version()}}, nil
+		}
+	case "~":
+		switch p.size {
+		case 0:
+			return all, nil
+		case 1:
+			return between(p.version(), &Version{Major: p.major + 1}), nil
+		default:
+			return between(p.version(), &Version{Major: p.major, Minor: p.minor + 1}), nil
+		}
+	case "^":
+		switch {
+		case p.size == 0:
+			return all, nil
+		case p.major > 0 || p.size == 1:
+			return between(p.version(), &Version{Major: p.major + 1}), nil
+		case p.minor > 0 || p.size == 2:
+			return between(p.version(), &Version{Minor: p.minor + 1}), nil
+		default:
+			return between(p.version(), &Version{Patch: p.patch + 1}), nil
+		}
+	}
+
+	return nil, ErrInvalidRange
+}
@@ -0,0 +1,128 @@
+package semver
+
+import (
+	"testing"
+
+	"github.com/pkg/errors"
+)
+
+func TestRangeContains(t *testing.T) {
+	tests := []struct {
+		r        string
+		match    []string
+		notMatch []string
+	}{
+		// caret
+		{r: "^1.2", match: []string{"v1.2.0", "v1.2.9", "v1.9.0"}, notMatch: []string{"v1.1.9", "v2.0.0"}},
+		{r: "^1.2.3", match: []string{"v1.2.3", "v1.3.0"}, notMatch: []string{"v1.2.2", "v2.0.0"}},
+		{r: "^1", match: []string{"v1.0.0", "v1.46.3"}, notMatch: []string{"v0.42.0", "v2.0.0"}},
+		{r: "^0.2", match: []string{"v0.2.0", "v0.2.5"}, notMatch: []string{"v0.3.0", "v0.1.9"}},
+		{r: "^0.0.3", match: []string{"v0.0.3"}, notMatch: []string{"v0.0.4", "v0.1.0"}},
+		// tilde
+		{r: "~1.4", match: []string{"v1.4.0", "v1.4.9"}, notMatch: []string{"v1.5.0", "v1.3.9"}},
+		{r: "~1.4.2", match: []string{"v1.4.2", "v1.4.9"}, notMatch: []string{"v1.4.1", "v1.5.0"}},
+		{r: "~>1.4.2", match: []string{"v1.4.2"}, notMatch: []string{"v1.5.0"}},
+		{r: "~1", match: []string{"v1.0.0", "v1.9.9"}, notMatch: []string{"v2.0.0"}},
+		// wildcards and partial versions
+		{r: "1.x", match: []string{"v1.0.0", "v1.46.3"}, notMatch: []string{"v2.0.0", "v0.9.0"}},
+		{r: "1.4.*", match: []string{"v1.4.0", "v1.4.7"}, notMatch: []string{"v1.5.0"}},
+		{r: "1.4", match: []string{"v1.4.0", "v1.4.7"}, notMatch: []string{"v1.5.0"}},
+		{r: "v1.4.2", match: []string{"v1.4.2"}, notMatch: []string{"v1.4.3"}},
+		{r: "=1.4.2", match: []string{"v1.4.2"}, notMatch: []string{"v1.4.1"}},
+		{r: "*", match: []string{"v0.1.0", "v1.46.3"}},
+		{r: "x", match: []string{"v0.1.0", "v1.46.3"}},
+		// latest
+		{r: "latest", match: []string{"v0.1.0", "v1.46.3"}, notMatch: []string{"v1.47.0-rc.1"}},
+		// comparators
+		{r: ">=1.3 <2", match: []string{"v1.3.0", "v1.99.0"}, notMatch: []string{"v1.2.9", "v2.0.0"}},
+		{r: ">= 1.3 < 2", match: []string{"v1.3.0"}, notMatch: []string{"v2.0.0"}},
+		{r: ">1.4", match: []string{"v1.5.0"}, notMatch: []string{"v1.4.9"}},
+		{r: ">1.4.2", match: []string{"v1.4.3"}, notMatch: []string{"v1.4.2"}},
+		{r: "<=1.4", match: []string{"v1.4.9"}, notMatch: []string{"v1.5.0"}},
+		{r: "<1.4.2", match: []string{"v1.4.1"}, notMatch: []string{"v1.4.2"}},
+		// hyphen
+		{r: "1.2 - 1.4", match: []string{"v1.2.0", "v1.4.9"}, notMatch: []string{"v1.1.9", "v1.5.0"}},
+		{r: "1.2.3 - 1.4.0", match: []string{"v1.2.3", "v1.4.0"}, notMatch: []string{"v1.2.2", "v1.4.1"}},
+		// compound
+		{r: "1.2 || ^2", match: []string{"v1.2.5", "v2.3.0"}, notMatch: []string{"v1.3.0", "v3.0.0"}},
+		{r: "~1.4 || >=1.10 <1.12", match: []string{"v1.4.1", "v1.10.0", "v1.11.9"}, notMatch: []string{"v1.5.0", "v1.12.0"}},
+		// pre-releases only match if the range mentions one of the same version core
+		{r: "^1.4", match: []string{"v1.4.0"}, notMatch: []string{"v1.5.0-rc.1"}},
+		{r: ">=1.5.0-rc.1", match: []string{"v1.5.0-rc.1", "v1.5.0-rc.2", "v1.5.0", "v1.6.0"}, notMatch: []string{"v1.5.0-beta", "v1.6.0-rc.1"}},
+		{r: "v1.5.0-rc.1", match: []string{"v1.5.0-rc.1"}, notMatch: []string{"v1.5.0-rc.2", "v1.5.0"}},
+	}
+
+	for _, test := range tests {
+		r, err := ParseRange(test.r)
+
+		if err != nil {
+			t.Fatalf("parse `%s` fail: %s", test.r, err)
+		}
+
+		for _, s := range test.match {
+			if v, _ := Parse(s); !r.Contains(v) {
+				t.Errorf("expect `%s` contains %s", test.r, s)
+			}
+		}
+
+		for _, s := range test.notMatch {
+			if v, _ := Parse(s); r.Contains(v) {
+				t.Errorf("expect `%s` does not contain %s", test.r, s)
+			}
+		}
+	}
+}
+
+func TestParseRangeInvalid(t *testing.T) {
+	tests := []string{
+		"",
+		"   ",
+		"^1 || ",
+		" || ^1",
+		"^1 |||| ^2",
+		">=",
+		">= ",
+		"^",
+		"~",
+		">=1.3 <",
+		"v",
+		"1.2.3.4",
+		"1.02",
+		"a.b.c",
+		"1.2-rc.1",
+		"1.2 -",
+		"1.2 - ",
+		"- 1.2",
+		"lastest",
+	}
+
+	for _, s := range tests {
+		if r, err := ParseRange(s); errors.Cause(err) != ErrInvalidRange {
+			t.Errorf("expect %v for `%s`, got %v and %v", ErrInvalidRange, s, r, err)
+		}
+	}
+}
+
+func TestRangeFilter(t *testing.T) {
+	r, err := ParseRange("^1.4")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	versions := make([]*Version, 0)
+
+	for _, s := range []string{"v1.3.0", "v1.4.0", "v1.4.2", "v1.5.0-rc.1", "v2.0.0"} {
+		v, err := Parse(s)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		versions = append(versions, v)
+	}
+
+	if max := Max(r.Filter(versions)); max == nil || max.String() != "v1.4.2" {
+		t.Fatalf("expect v1.4.2, got %v", max)
+	}
+}
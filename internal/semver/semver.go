@@ -0,0 +1,183 @@
+package semver
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+var (
+	ErrInvalidVersion = errors.New("invalid version")
+)
+
+// Version is a parsed semantic version such as `v1.4.2` or `1.0.0-rc.1`
+type Version struct {
+	Major      int
+	Minor      int
+	Patch      int
+	Prerelease string
+	Original   string
+}
+
+// Parse a full version, the leading `v` is optional
+func Parse(s string) (*Version, error) {
+	raw := strings.TrimPrefix(strings.TrimSpace(s), "v")
+
+	// drop build metadata, it does not take part in precedence
+	if i := strings.Index(raw, "+"); i >= 0 {
+		raw = raw[:i]
+	}
+
+	prerelease := ""
+
+	if i := strings.Index(raw, "-"); i >= 0 {
+		prerelease = raw[i+1:]
+		raw = raw[:i]
+
+		if prerelease == "" {
+			return nil, errors.Wrapf(ErrInvalidVersion, "`%s`", s)
+		}
+	}
+
+	parts := strings.Split(raw, ".")
+
+	if len(parts) != 3 {
+		return nil, errors.Wrapf(ErrInvalidVersion, "`%s`", s)
+	}
+
+	numbers := make([]int, 3)
+
+	for i, part := range parts {
+		n, err := parseNumber(part)
+
+		if err != nil {
+			return nil, errors.Wrapf(ErrInvalidVersion, "`%s`", s)
+		}
+
+		numbers[i] = n
+	}
+
+	return &Version{
+		Major:      numbers[0],
+		Minor:      numbers[1],
+		Patch:      numbers[2],
+		Prerelease: prerelease,
+		Original:   strings.TrimSpace(s),
+	}, nil
+}
+
+// String returns the version in Deno's tag format, eg `v1.4.2`
+func (v *Version) String() string {
+	s := fmt.Sprintf("v%d.%d.%d", v.Major, v.Minor, v.Patch)
+
+	if v.Prerelease != "" {
+		s += "-" + v.Prerelease
+	}
+
+	return s
+}
+
+// Compare returns -1, 0 or 1 if v is lower, equal or greater than o
+func (v *Version) Compare(o *Version) int {
+	if c := compareInt(v.Major, o.Major); c != 0 {
+		return c
+	}
+
+	if c := compareInt(v.Minor, o.Minor); c != 0 {
+		return c
+	}
+
+	if c := compareInt(v.Patch, o.Patch); c != 0 {
+		return c
+	}
+
+	return comparePrerelease(v.Prerelease, o.Prerelease)
+}
+
+// LessThan reports whether v is lower than o
+func (v *Version) LessThan(o *Version) bool {
+	return v.Compare(o) < 0
+}
+
+// Max returns the greatest version of the list, nil if the list is empty
+func Max(versions []*Version) *Version {
+	var max *Version
+
+	for _, v := range versions {
+		if max == nil || max.LessThan(v) {
+			max = v
+		}
+	}
+
+	return max
+}
+
+func parseNumber(s string) (int, error) {
+	if s == "" {
+		return 0, ErrInvalidVersion
+	}
+
+	// leading zeros are not allowed, except for 0 itself
+	if len(s) > 1 && s[0] == '0' {
+		return 0, ErrInvalidVersion
+	}
+
+	n, err := strconv.Atoi(s)
+
+	if err != nil || n < 0 {
+		return 0, ErrInvalidVersion
+	}
+
+	return n, nil
+}
+
+func compareInt(a, b int) int {
+	if a < b {
+		return -1
+	} else if a > b {
+		return 1
+	}
+
+	return 0
+}
+
+// compare pre-release identifiers as described at https://semver.org/#spec-item-11
+func comparePrerelease(a, b string) int {
+	if a == b {
+		return 0
+	}
+
+	// a version without pre-release has higher precedence
+	if a == "" {
+		return 1
+	} else if b == "" {
+		return -1
+	}
+
+	aParts := strings.Split(a, ".")
+	bParts := strings.Split(b, ".")
+
+	for i := 0; i < len(aParts) && i < len(bParts); i++ {
+		aNum, aErr := strconv.Atoi(aParts[i])
+		bNum, bErr := strconv.Atoi(bParts[i])
+
+		switch {
+		case aErr == nil && bErr == nil:
+			if c := compareInt(aNum, bNum); c != 0 {
+				return c
+			}
+		case aErr == nil:
+			return -1
+		case bErr == nil:
+			return 1
+		default:
+			if c := strings.Compare(aParts[i], bParts[i]); c != 0 {
+				return c
+			}
+		}
+	}
+
+	return compareInt(len(aParts), len(bParts))
+}
@@ -0,0 +1,48 @@
+// Package testutil contains the helpers shared by the tests
+package testutil
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+// TempDir create a temporary dir for the test, call clean to remove it
+func TempDir(t *testing.T) (dir string, clean func()) {
+	dir, err := ioutil.TempDir("", "denox-test")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return dir, func() {
+		_ = os.RemoveAll(dir)
+	}
+}
+
+// Setenv set the environment variables for the test, call the returned function to restore them
+func Setenv(t *testing.T, env map[string]string) func() {
+	old := make(map[string]*string)
+
+	for key, value := range env {
+		if v, ok := os.LookupEnv(key); ok {
+			old[key] = &v
+		} else {
+			old[key] = nil
+		}
+
+		if err := os.Setenv(key, value); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return func() {
+		for key, value := range old {
+			if value == nil {
+				_ = os.Unsetenv(key)
+			} else {
+				_ = os.Setenv(key, *value)
+			}
+		}
+	}
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"fmt"
 	"log"
 	"os"
 	"os/exec"
@@ -8,6 +9,8 @@ import (
 	"syscall"
 
 	"github.com/axetroy/denox/internal/deno"
+	"github.com/axetroy/denox/internal/logger"
+	"github.com/axetroy/denox/internal/progress"
 	"github.com/axetroy/denox/internal/signals"
 	"github.com/pkg/errors"
 )
@@ -15,10 +18,19 @@ import (
 func main() {
 	args := os.Args
 
+	// the commands of denox itself, Deno has no subcommand `x`
+	if len(args) > 1 && args[1] == "x" {
+		os.Exit(runCommand(args[2:]))
+	}
+
+	versionFlag, denoArgs, err := parseDenoxFlags(args[1:])
+
+	if err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "denox: %s, see `denox x help`\n", err)
+		os.Exit(2)
+	}
+
 	var (
-		err          error
-		denoArgs     = args[1:]
-		denoVersion  *string
 		denoExitCode int
 		cmd          *exec.Cmd
 	)
@@ -31,8 +43,25 @@ func main() {
 		os.Exit(denoExitCode)
 	}()
 
-	if v := os.Getenv("DENO_VERSION"); v != "" {
-		denoVersion = &v
+	var (
+		denoVersion *string
+		from        string
+	)
+
+	if versionFlag != "" {
+		logger.Debugf("use version `%s` from %s", versionFlag, deno.VersionFromFlag)
+		denoVersion, from = &versionFlag, deno.VersionFromFlag
+	} else {
+		var cwd string
+
+		if cwd, err = os.Getwd(); err != nil {
+			err = errors.Wrap(err, "get current working dir fail")
+			return
+		}
+
+		if denoVersion, from, err = deno.LookupVersion(cwd); err != nil {
+			return
+		}
 	}
 
 	d, err := deno.New(denoVersion)
@@ -41,9 +70,19 @@ func main() {
 		return
 	}
 
+	logger.Debugf("resolved Deno version `%s`", d.Version)
+
+	spec := "latest"
+
+	if denoVersion != nil {
+		spec = *denoVersion
+	}
+
+	progress.Default().Event("resolve", progress.Fields{"spec": spec, "from": from, "version": d.Version})
+
 	defer d.Clean()
 
-	quit := make(chan os.Signal)
+	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, os.Kill, syscall.SIGHUP, syscall.SIGINT, syscall.SIGTERM, syscall.SIGQUIT)
 
 	go func() {
@@ -68,32 +107,49 @@ func main() {
 		return
 	}
 
-	signalProxy := make(chan os.Signal)
+	if err := d.MarkUsed(); err != nil {
+		logger.Debugf("%s", err)
+	}
+
+	if err = d.PrepareDenoDir(); err != nil {
+		return
+	}
+
+	signalProxy := make(chan os.Signal, 1)
 	signal.Notify(signalProxy, signals.AllSignals...)
 
 	go func() {
-		s := <-signalProxy
-		if cmd != nil && !cmd.ProcessState.Exited() {
-			if err = cmd.Process.Signal(s); err != nil {
-				err = errors.Wrapf(err, "send signal `%s` fail", s)
+		for s := range signalProxy {
+			// the process has not started or has exited
+			if s == signals.SIGCHLD || cmd == nil || cmd.Process == nil || cmd.ProcessState != nil {
+				continue
+			}
+
+			if err := cmd.Process.Signal(s); err != nil {
+				logger.Debugf("send signal `%s` fail: %s", s, err)
 			}
 		}
 	}()
 
-	if err := os.Setenv("DENO_DIR", d.DenoDir); err != nil {
+	if err = os.Setenv("DENO_DIR", d.DenoDir); err != nil {
 		err = errors.Wrapf(err, "set env $DENO_DIR=%s fail", d.DenoDir)
 		return
 	}
 
+	denoArgs = deno.WithDefaultFlags(denoArgs)
+
 	cmd = exec.Command(executablePath, denoArgs...)
 
 	cmd.Stdin = os.Stdin
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
 
-	if err := cmd.Run(); err != nil {
+	progress.Default().Event("run", progress.Fields{"executable": executablePath, "args": denoArgs})
+
+	if err = cmd.Run(); err != nil {
 		if exitError, ok := err.(*exec.ExitError); ok {
 			denoExitCode = exitError.ExitCode()
+			err = nil
 		} else {
 			err = errors.Wrap(err, "run command fail")
 		}
@@ -0,0 +1,413 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	"github.com/axetroy/denox/internal/deno"
+	"github.com/axetroy/denox/internal/logger"
+	"github.com/axetroy/denox/internal/semver"
+	"github.com/pkg/errors"
+)
+
+// print v as indented JSON to stdout
+func printJSON(v interface{}) error {
+	b, err := json.MarshalIndent(v, "", "  ")
+
+	if err != nil {
+		return errors.Wrap(err, "marshal JSON fail")
+	}
+
+	fmt.Println(string(b))
+
+	return nil
+}
+
+// format the bytes in the unit of 1024, eg `12.3 MiB`
+func formatSize(size int64) string {
+	const unit = 1024
+
+	if size < unit {
+		return fmt.Sprintf("%d B", size)
+	}
+
+	div, exp := int64(unit), 0
+
+	for n := size / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+
+	return fmt.Sprintf("%.1f %ciB", float64(size)/float64(div), "KMGTPE"[exp])
+}
+
+func formatTime(t *time.Time) string {
+	if t == nil {
+		return "-"
+	}
+
+	return t.Local().Format("2006-01-02 15:04")
+}
+
+type installCommand struct {
+	json   bool
+	verify bool
+}
+
+func (*installCommand) name() string { return "install" }
+
+func (*installCommand) usage() string { return "[--json] [--verify] [range...]" }
+
+func (*installCommand) description() string {
+	return "install the versions of Deno without running it, eg `denox x install ^1.4 v1.0.0`.\n" +
+		"install the version to use in the current working dir if no range is given"
+}
+
+func (c *installCommand) setFlags(flags *flag.FlagSet) {
+	flags.BoolVar(&c.json, "json", false, "print the result in JSON")
+	flags.BoolVar(&c.verify, "verify", false, "verify the hash of the installed version and reinstall it if it does not match")
+}
+
+func (c *installCommand) run(args []string) error {
+	type result struct {
+		Spec       string `json:"spec"`
+		Version    string `json:"version"`
+		Executable string `json:"executable"`
+	}
+
+	specs := make([]*string, 0, len(args))
+
+	for i := range args {
+		specs = append(specs, &args[i])
+	}
+
+	if len(specs) == 0 {
+		cwd, err := os.Getwd()
+
+		if err != nil {
+			return errors.Wrap(err, "get current working dir fail")
+		}
+
+		spec, _, err := deno.LookupVersion(cwd)
+
+		if err != nil {
+			return err
+		}
+
+		specs = append(specs, spec)
+	}
+
+	results := make([]result, 0, len(specs))
+
+	for _, spec := range specs {
+		d, err := deno.New(spec)
+
+		if err != nil {
+			return err
+		}
+
+		d.Verify = c.verify
+
+		executablePath, err := d.Download()
+
+		if cleanErr := d.Clean(); cleanErr != nil {
+			logger.Debugf("%s", cleanErr)
+		}
+
+		if err != nil {
+			return errors.Wrapf(err, "install Deno %s fail", d.Version)
+		}
+
+		r := result{Spec: "latest", Version: d.Version, Executable: executablePath}
+
+		if spec != nil {
+			r.Spec = *spec
+		}
+
+		results = append(results, r)
+
+		if !c.json {
+			fmt.Printf("Deno %s is installed at `%s`\n", r.Version, r.Executable)
+		}
+	}
+
+	if c.json {
+		return printJSON(results)
+	}
+
+	return nil
+}
+
+type uninstallCommand struct {
+	json bool
+}
+
+func (*uninstallCommand) name() string { return "uninstall" }
+
+func (*uninstallCommand) usage() string { return "[--json] <version...>" }
+
+func (*uninstallCommand) description() string {
+	return "uninstall the versions of Deno, including the module cache of DENO_DIR policy `per-version`"
+}
+
+func (c *uninstallCommand) setFlags(flags *flag.FlagSet) {
+	flags.BoolVar(&c.json, "json", false, "print the result in JSON")
+}
+
+func (c *uninstallCommand) run(args []string) error {
+	if len(args) == 0 {
+		return newUsageError("uninstall requires a version")
+	}
+
+	results := make([]*deno.Installation, 0, len(args))
+
+	for _, version := range args {
+		installation, err := deno.Uninstall(version)
+
+		if err != nil {
+			return err
+		}
+
+		results = append(results, installation)
+
+		if !c.json {
+			fmt.Printf("Deno %s is uninstalled, %s freed\n", installation.Version, formatSize(installation.Size))
+		}
+	}
+
+	if c.json {
+		return printJSON(results)
+	}
+
+	return nil
+}
+
+type listCommand struct {
+	json bool
+}
+
+func (*listCommand) name() string { return "list" }
+
+func (*listCommand) usage() string { return "[--json]" }
+
+func (*listCommand) description() string {
+	return "list the installed versions of Deno.\n" +
+		"the default is the newest installed version for the global default, " +
+		"and the pinned is the one for the pin file or DENO_VERSION in the current working dir"
+}
+
+func (c *listCommand) setFlags(flags *flag.FlagSet) {
+	flags.BoolVar(&c.json, "json", false, "print the result in JSON")
+}
+
+func (c *listCommand) run(args []string) error {
+	if len(args) > 0 {
+		return newUsageError("too many arguments")
+	}
+
+	type item struct {
+		*deno.Installation
+		Default bool `json:"default"`
+		Pinned  bool `json:"pinned"`
+	}
+
+	installations, err := deno.ListInstallations()
+
+	if err != nil {
+		return err
+	}
+
+	defaultVersion, pinnedVersion, err := getDefaultAndPinnedVersion()
+
+	if err != nil {
+		return err
+	}
+
+	items := make([]item, 0, len(installations))
+
+	for _, installation := range installations {
+		items = append(items, item{
+			Installation: installation,
+			Default:      installation.Version == defaultVersion,
+			Pinned:       installation.Version == pinnedVersion,
+		})
+	}
+
+	if c.json {
+		return printJSON(items)
+	}
+
+	if len(items) == 0 {
+		_, _ = fmt.Fprintln(os.Stderr, "no version of Deno is installed, install it with `denox x install <range>`")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+
+	_, _ = fmt.Fprintln(w, "VERSION\tSIZE\tINSTALLED\tLAST USED\t")
+
+	for _, item := range items {
+		note := ""
+
+		switch {
+		case item.Default && item.Pinned:
+			note = "default, pinned"
+		case item.Default:
+			note = "default"
+		case item.Pinned:
+			note = "pinned"
+		}
+
+		_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", item.Version, formatSize(item.Size), formatTime(item.InstalledAt), formatTime(item.LastUsedAt), note)
+	}
+
+	return w.Flush()
+}
+
+// get the installed versions for the global default and the pin file in the current working dir,
+// it is empty if there is no such version installed
+func getDefaultAndPinnedVersion() (defaultVersion string, pinnedVersion string, err error) {
+	if spec, err := deno.LookupGlobalVersion(); err != nil {
+		return "", "", err
+	} else if spec != nil {
+		defaultVersion, _ = deno.MatchInstalledVersion(*spec)
+	}
+
+	cwd, err := os.Getwd()
+
+	if err != nil {
+		return "", "", errors.Wrap(err, "get current working dir fail")
+	}
+
+	spec, from, err := deno.LookupVersion(cwd)
+
+	if err != nil {
+		return "", "", err
+	}
+
+	if spec != nil && (from == deno.VersionFromPinFile || from == deno.VersionFromEnv) {
+		pinnedVersion, _ = deno.MatchInstalledVersion(*spec)
+	}
+
+	return defaultVersion, pinnedVersion, nil
+}
+
+type listRemoteCommand struct {
+	json       bool
+	prerelease bool
+}
+
+func (*listRemoteCommand) name() string { return "list-remote" }
+
+func (*listRemoteCommand) usage() string { return "[--json] [--prerelease] [range]" }
+
+func (*listRemoteCommand) description() string {
+	return "list the versions of Deno which can be installed, from the newest to the oldest"
+}
+
+func (c *listRemoteCommand) setFlags(flags *flag.FlagSet) {
+	flags.BoolVar(&c.json, "json", false, "print the result in JSON")
+	flags.BoolVar(&c.prerelease, "prerelease", false, "include the pre-releases")
+}
+
+func (c *listRemoteCommand) run(args []string) error {
+	if len(args) > 1 {
+		return newUsageError("too many arguments")
+	}
+
+	var r *semver.Range
+
+	if len(args) == 1 {
+		var err error
+
+		if r, err = semver.ParseRange(args[0]); err != nil {
+			return newUsageError("%s", err)
+		}
+	}
+
+	type item struct {
+		Version    string `json:"version"`
+		Prerelease bool   `json:"prerelease"`
+		Installed  bool   `json:"installed"`
+	}
+
+	releases, err := deno.ListReleases()
+
+	if err != nil {
+		return err
+	}
+
+	installations, err := deno.ListInstallations()
+
+	if err != nil {
+		return err
+	}
+
+	installed := make(map[string]bool)
+
+	for _, installation := range installations {
+		installed[installation.Version] = true
+	}
+
+	versions := make([]*semver.Version, 0, len(releases))
+	prereleases := make(map[*semver.Version]bool)
+
+	for _, release := range releases {
+		v, err := semver.Parse(release.Tag)
+
+		// ignore the tag which is not semver
+		if err != nil {
+			continue
+		}
+
+		prerelease := release.Prerelease || v.Prerelease != ""
+
+		if prerelease && !c.prerelease {
+			continue
+		}
+
+		// the pre-release matches if its version core matches, eg `v1.5.0-rc.1` for `^1.5`
+		core := *v
+		core.Prerelease = ""
+
+		if r != nil && !r.Contains(&core) {
+			continue
+		}
+
+		versions = append(versions, v)
+		prereleases[v] = prerelease
+	}
+
+	sort.SliceStable(versions, func(i, j int) bool {
+		return versions[j].LessThan(versions[i])
+	})
+
+	items := make([]item, 0, len(versions))
+
+	for _, v := range versions {
+		items = append(items, item{Version: v.String(), Prerelease: prereleases[v], Installed: installed[v.String()]})
+	}
+
+	if c.json {
+		return printJSON(items)
+	}
+
+	if len(items) == 0 && r != nil {
+		return errors.Errorf("no version of Deno matches `%s`", r)
+	}
+
+	for _, item := range items {
+		if item.Installed {
+			fmt.Printf("%s (installed)\n", item.Version)
+		} else {
+			fmt.Println(item.Version)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,24 @@
+package main
+
+import "testing"
+
+func TestFormatSize(t *testing.T) {
+	tests := []struct {
+		size     int64
+		expected string
+	}{
+		{size: 0, expected: "0 B"},
+		{size: 1023, expected: "1023 B"},
+		{size: 1024, expected: "1.0 KiB"},
+		{size: 1536, expected: "1.5 KiB"},
+		{size: 1024 * 1024, expected: "1.0 MiB"},
+		{size: 95 * 1024 * 1024, expected: "95.0 MiB"},
+		{size: 3 * 1024 * 1024 * 1024, expected: "3.0 GiB"},
+	}
+
+	for _, test := range tests {
+		if actual := formatSize(test.size); actual != test.expected {
+			t.Errorf("expect `%s` for %d, got `%s`", test.expected, test.size, actual)
+		}
+	}
+}